@@ -26,6 +26,9 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"go.linka.cloud/protodb-controller/pkg/controller"
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+	"go.linka.cloud/protodb-controller/pkg/source"
 )
 
 type Message[T any] interface {
@@ -45,7 +48,36 @@ func (fn KeyFunc[T, K]) Key(m T) K {
 
 type Controller interface {
 	Start(ctx context.Context) error
+	// Sync lists the objects again and enqueues them. It does not block, the pending resyncs are coalesced.
 	Sync()
+	// Synced returns true once the objects were listed and the workers started.
+	// It can be used as a readiness check with healthz.SyncedCheck.
+	Synced() bool
+	// Status returns the current state of the controller.
+	Status() controller.Status
+	// Pause stops the workers from dequeuing keys while the source keeps adding them to the queue.
+	// It can be called before the controller is started.
+	Pause()
+	// Resume resumes a paused controller, processing the keys accumulated in the queue.
+	Resume()
+	// SetWorkers sets the number of workers. It fails if autoscaling is enabled.
+	SetWorkers(n int) error
+}
+
+// TypedController is a Controller reconciling keys of type K.
+type TypedController[K comparable] interface {
+	Controller
+	// Redrive removes the keys from the dead-letter store and enqueues them again.
+	Redrive(ctx context.Context, keys ...K) error
+	// Inspect returns the content of the queue.
+	Inspect() ([]priorityqueue.ItemInfo[K], error)
+	// Enqueue adds the keys to the queue.
+	Enqueue(keys ...K) error
+	// Forget removes the keys waiting in the queue and resets their retries.
+	Forget(keys ...K) error
+	// Watch adds a source of keys to reconcile alongside the protodb objects,
+	// e.g. a source.Channel, source.Ticker or source.Kind.
+	Watch(src source.TypedSource[K]) error
 }
 
 type ctrl[T any, PT Message[T], K comparable] struct {
@@ -53,7 +85,7 @@ type ctrl[T any, PT Message[T], K comparable] struct {
 	s *src[T, PT, K]
 }
 
-func New[T any, PT Message[T], K comparable](name string, db protodb.Client, fn Key[PT, K], options Options[K]) (Controller, error) {
+func New[T any, PT Message[T], K comparable](name string, db protodb.Client, fn Key[PT, K], options Options[K], opts ...SourceOpt[T, PT]) (TypedController[K], error) {
 	var z PT
 	t := z.ProtoReflect().Descriptor().FullName()
 	if db == nil {
@@ -74,11 +106,24 @@ func New[T any, PT Message[T], K comparable](name string, db protodb.Client, fn
 			)
 		}
 	}
+	s := newSrc[T, PT, K](typed.NewStore[T, PT](db), fn.Key, opts...)
+	if s.recorder != nil && options.Reconciler != nil {
+		r := options.Reconciler
+		options.Reconciler = reconcile.TypedFunc[K](func(ctx context.Context, req K) (reconcile.Result, error) {
+			res, err := r.Reconcile(ctx, req)
+			s.recorder.RecordReconcile(req, res, err)
+			return res, err
+		})
+	}
 	c, err := controller.NewTypedUnmanaged[K](name, options)
 	if err != nil {
 		return nil, err
 	}
-	return &ctrl[T, PT, K]{s: newSrc[T, PT, K](typed.NewStore[T, PT](db), fn.Key), c: c}, nil
+	if options.Sharder != nil {
+		// list the objects again to enqueue the keys gained when the assignment changes
+		options.Sharder.OnChange(s.Sync)
+	}
+	return &ctrl[T, PT, K]{s: s, c: c}, nil
 }
 
 func (c *ctrl[T, PT, K]) Start(ctx context.Context) error {
@@ -91,3 +136,43 @@ func (c *ctrl[T, PT, K]) Start(ctx context.Context) error {
 func (c *ctrl[T, PT, K]) Sync() {
 	c.s.Sync()
 }
+
+func (c *ctrl[T, PT, K]) Synced() bool {
+	return c.c.Synced()
+}
+
+func (c *ctrl[T, PT, K]) Pause() {
+	c.c.Pause()
+}
+
+func (c *ctrl[T, PT, K]) Resume() {
+	c.c.Resume()
+}
+
+func (c *ctrl[T, PT, K]) SetWorkers(n int) error {
+	return c.c.SetWorkers(n)
+}
+
+func (c *ctrl[T, PT, K]) Status() controller.Status {
+	return c.c.Status()
+}
+
+func (c *ctrl[T, PT, K]) Redrive(ctx context.Context, keys ...K) error {
+	return c.c.Redrive(ctx, keys...)
+}
+
+func (c *ctrl[T, PT, K]) Inspect() ([]priorityqueue.ItemInfo[K], error) {
+	return c.c.Inspect()
+}
+
+func (c *ctrl[T, PT, K]) Enqueue(keys ...K) error {
+	return c.c.Enqueue(keys...)
+}
+
+func (c *ctrl[T, PT, K]) Forget(keys ...K) error {
+	return c.c.Forget(keys...)
+}
+
+func (c *ctrl[T, PT, K]) Watch(src source.TypedSource[K]) error {
+	return c.c.Watch(src)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"time"
+)
+
+// LowPriority is the priority commonly used for items that do not need
+// to be reconciled before anything else, e.g. the objects listed when the
+// controller starts.
+const LowPriority = -100
+
+// EventType describes why the source enqueued a key.
+type EventType int
+
+const (
+	// EventTypeInitialList is used for the objects listed when the source starts.
+	EventTypeInitialList EventType = iota
+	// EventTypeResync is used for the objects listed after a call to Controller.Sync.
+	EventTypeResync
+	// EventTypeCreate is used when an object was created.
+	EventTypeCreate
+	// EventTypeUpdate is used when an object was updated.
+	EventTypeUpdate
+	// EventTypeDelete is used when an object was deleted.
+	EventTypeDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventTypeInitialList:
+		return "initial_list"
+	case EventTypeResync:
+		return "resync"
+	case EventTypeCreate:
+		return "create"
+	case EventTypeUpdate:
+		return "update"
+	case EventTypeDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is the event that led the source to enqueue a key.
+type Event[T any, PT Message[T]] struct {
+	Type EventType
+	// Old is the previous version of the object, it is only set for update and delete events.
+	Old PT
+	// New is the current version of the object, it is not set for delete events.
+	New PT
+	// Time is the time the event was received from the watch, or the time the objects were listed.
+	Time time.Time
+}
+
+// Object returns the object the event is about.
+func (e Event[T, PT]) Object() PT {
+	if e.Type == EventTypeDelete {
+		return e.Old
+	}
+	return e.New
+}
+
+// PriorityFunc returns the priority used to enqueue the key of the event's object.
+// Priorities are only taken into account when the controller uses a priority queue,
+// see Options.NewQueue and priorityqueue.New.
+type PriorityFunc[T any, PT Message[T]] func(e Event[T, PT]) int
+
+// PriorityByType returns a PriorityFunc using the given priority for each event type.
+// Event types missing from the map are enqueued with the priority 0.
+func PriorityByType[T any, PT Message[T]](priorities map[EventType]int) PriorityFunc[T, PT] {
+	return func(e Event[T, PT]) int {
+		return priorities[e.Type]
+	}
+}
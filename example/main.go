@@ -27,6 +27,9 @@ import (
 
 	controller "go.linka.cloud/protodb-controller"
 	"go.linka.cloud/protodb-controller/example/pb"
+	"go.linka.cloud/protodb-controller/pkg/debug"
+	"go.linka.cloud/protodb-controller/pkg/healthz"
+	"go.linka.cloud/protodb-controller/pkg/server"
 )
 
 //go:generate buf generate
@@ -82,6 +85,16 @@ func main() {
 		log.Fatal(err)
 	}
 
+	srv := server.New(server.Options{}, server.WithControllers(map[string]healthz.Synced{"noop": c}))
+	if err := debug.Handle[string](srv, "noop", c); err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
 	go func() {
 		logger.C(ctx).Info("creating resource")
 		if _, err := db.Set(ctx, &pb.Resource{ID: uuid.NewString()}); err != nil {
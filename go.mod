@@ -10,7 +10,12 @@ require (
 	github.com/prometheus/client_golang v1.21.0
 	go.linka.cloud/grpc-toolkit v0.4.4-0.20231026145832-5d6b16a2c2a0
 	go.linka.cloud/protodb v0.0.0-20250402152034-592ac70029a5
+	go.linka.cloud/protofilters v0.8.2-0.20250209153700-12f397dfb6a5
+	go.opentelemetry.io/otel v1.32.0
+	go.opentelemetry.io/otel/trace v1.32.0
 	golang.org/x/sync v0.11.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250219182151-9fdb1cabc7b2
+	google.golang.org/grpc v1.70.0
 	google.golang.org/protobuf v1.36.5
 	k8s.io/apimachinery v0.32.1
 	k8s.io/client-go v0.32.1
@@ -77,7 +82,6 @@ require (
 	go.linka.cloud/protoc-gen-defaults v0.4.0 // indirect
 	go.linka.cloud/protoc-gen-go-fields v0.4.0 // indirect
 	go.linka.cloud/protoc-gen-proxy v0.0.0-20230802234945-cc173b85cf13 // indirect
-	go.linka.cloud/protofilters v0.8.2-0.20250209153700-12f397dfb6a5 // indirect
 	go.linka.cloud/pubsub v0.0.0-20220728154114-8213058139f3 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
@@ -88,8 +92,6 @@ require (
 	golang.org/x/time v0.8.0 // indirect
 	golang.org/x/tools v0.30.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20250219182151-9fdb1cabc7b2 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20250219182151-9fdb1cabc7b2 // indirect
-	google.golang.org/grpc v1.70.0 // indirect
 	google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.5.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
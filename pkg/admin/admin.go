@@ -0,0 +1,234 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin provides the implementation of the pb.Admin gRPC service,
+// allowing operators to inspect and act on the controllers registered in a Server.
+package admin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.linka.cloud/protodb-controller/pkg/codec"
+	"go.linka.cloud/protodb-controller/pkg/controller"
+	"go.linka.cloud/protodb-controller/pkg/pb"
+)
+
+// Controller is implemented by the controllers.
+type Controller[K comparable] interface {
+	Sync()
+	Status() controller.Status
+	Enqueue(keys ...K) error
+	Pause()
+	Resume()
+	SetWorkers(n int) error
+}
+
+// Opts contains the options of a registered controller.
+type Opts[K comparable] struct {
+	// Codec decodes the keys received in the Reconcile requests. Defaults to codec.JSON.
+	Codec codec.Codec[K]
+}
+
+// Opt allows to configure a registered controller.
+type Opt[K comparable] func(*Opts[K])
+
+// WithCodec sets the codec used to decode the keys.
+func WithCodec[K comparable](c codec.Codec[K]) Opt[K] {
+	return func(o *Opts[K]) {
+		o.Codec = c
+	}
+}
+
+// entry is a registered controller with its keys type erased.
+type entry interface {
+	sync()
+	status() controller.Status
+	reconcile(keys []string) error
+	pause()
+	resume()
+	setWorkers(n int) error
+}
+
+// Server implements pb.AdminServer.
+type Server struct {
+	pb.UnimplementedAdminServer
+
+	mu          sync.RWMutex
+	controllers map[string]entry
+}
+
+// New returns a new Server without controllers.
+func New() *Server {
+	return &Server{controllers: map[string]entry{}}
+}
+
+// Register registers the named controller in the server.
+func Register[K comparable](s *Server, name string, c Controller[K], o ...Opt[K]) error {
+	opts := Opts[K]{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.JSON[K]{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.controllers[name]; ok {
+		return fmt.Errorf("controller %s already registered", name)
+	}
+	s.controllers[name] = &typedEntry[K]{c: c, codec: opts.Codec}
+	return nil
+}
+
+// Register registers the service on the gRPC server.
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	pb.RegisterAdminServer(r, s)
+}
+
+func (s *Server) List(_ context.Context, _ *pb.ListRequest) (*pb.ListResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := &pb.ListResponse{}
+	for name, e := range s.controllers {
+		st := e.status()
+		res.Controllers = append(res.Controllers, &pb.ControllerStatus{
+			Name:          name,
+			Started:       st.Started,
+			Synced:        st.Synced,
+			Paused:        st.Paused,
+			Workers:       int64(st.Workers),
+			ActiveWorkers: int64(st.ActiveWorkers),
+			QueueDepth:    int64(st.QueueDepth),
+		})
+	}
+	slices.SortFunc(res.Controllers, func(a, b *pb.ControllerStatus) int {
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+	return res, nil
+}
+
+func (s *Server) Sync(_ context.Context, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	e, err := s.get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	e.sync()
+	return &pb.SyncResponse{}, nil
+}
+
+func (s *Server) Pause(_ context.Context, req *pb.PauseRequest) (*pb.PauseResponse, error) {
+	e, err := s.get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	e.pause()
+	return &pb.PauseResponse{}, nil
+}
+
+func (s *Server) Resume(_ context.Context, req *pb.ResumeRequest) (*pb.ResumeResponse, error) {
+	e, err := s.get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	e.resume()
+	return &pb.ResumeResponse{}, nil
+}
+
+func (s *Server) SetWorkers(_ context.Context, req *pb.SetWorkersRequest) (*pb.SetWorkersResponse, error) {
+	e, err := s.get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if req.Workers <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "workers must be positive")
+	}
+	if err := e.setWorkers(int(req.Workers)); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &pb.SetWorkersResponse{}, nil
+}
+
+func (s *Server) Reconcile(_ context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	e, err := s.get(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.reconcile(req.Keys); err != nil {
+		return nil, err
+	}
+	return &pb.ReconcileResponse{}, nil
+}
+
+func (s *Server) get(name string) (entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.controllers[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "controller %s not found", name)
+	}
+	return e, nil
+}
+
+type typedEntry[K comparable] struct {
+	c     Controller[K]
+	codec codec.Codec[K]
+}
+
+func (e *typedEntry[K]) sync() {
+	e.c.Sync()
+}
+
+func (e *typedEntry[K]) status() controller.Status {
+	return e.c.Status()
+}
+
+func (e *typedEntry[K]) reconcile(keys []string) error {
+	ks := make([]K, 0, len(keys))
+	for _, v := range keys {
+		k, err := e.codec.Unmarshal([]byte(v))
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid key %q: %v", v, err)
+		}
+		ks = append(ks, k)
+	}
+	if err := e.c.Enqueue(ks...); err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return nil
+}
+
+func (e *typedEntry[K]) pause() {
+	e.c.Pause()
+}
+
+func (e *typedEntry[K]) resume() {
+	e.c.Resume()
+}
+
+func (e *typedEntry[K]) setWorkers(n int) error {
+	return e.c.SetWorkers(n)
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission provides the defaulting and the validation of the messages written to protodb.
+//
+// The defaulters and validators are registered by message type in a Registry, and run on each Set
+// by the client returned by NewClient, which rejects the invalid messages with a *ValidationError:
+//
+//	r := admission.NewRegistry()
+//	admission.RegisterDefaulter(r, func(ctx context.Context, m *pb.Resource) error {
+//		if m.Replicas == 0 {
+//			m.Replicas = 1
+//		}
+//		return nil
+//	})
+//	admission.RegisterValidator(r, func(ctx context.Context, m *pb.Resource) error {
+//		if m.Replicas > 10 {
+//			return admission.Invalid("replicas", "must be less than or equal to 10")
+//		}
+//		return nil
+//	})
+//	db = admission.NewClient(db, r)
+package admission
+
+import (
+	"context"
+	"sync"
+
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Defaulter sets the default values of a message before it is validated and written.
+type Defaulter func(ctx context.Context, m proto.Message) error
+
+// Validator validates a message before it is written. It returns a *ValidationError,
+// e.g. built with Invalid, to report the invalid fields, or any other error which is
+// reported as a violation of the whole message.
+type Validator func(ctx context.Context, m proto.Message) error
+
+// RegistryOpts contains the options of a Registry.
+type RegistryOpts struct {
+	// Rules, if set, validates all the messages, whatever their type, after the registered validators.
+	// It allows to enforce the rules declared on the descriptors, e.g. with protovalidate:
+	//
+	//	v, _ := protovalidate.New()
+	//	r := admission.NewRegistry(admission.WithRules(func(m proto.Message) error { return v.Validate(m) }))
+	Rules func(m proto.Message) error
+}
+
+// RegistryOpt allows to configure a Registry.
+type RegistryOpt func(*RegistryOpts)
+
+// WithRules sets the function validating all the messages against the rules declared on their descriptors.
+func WithRules(fn func(m proto.Message) error) RegistryOpt {
+	return func(o *RegistryOpts) {
+		o.Rules = fn
+	}
+}
+
+// Registry holds the defaulters and the validators by message type. It is safe for concurrent use.
+type Registry struct {
+	opts RegistryOpts
+
+	mu         sync.RWMutex
+	defaulters map[protoreflect.FullName][]Defaulter
+	validators map[protoreflect.FullName][]Validator
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry(o ...RegistryOpt) *Registry {
+	opts := RegistryOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	return &Registry{
+		opts:       opts,
+		defaulters: make(map[protoreflect.FullName][]Defaulter),
+		validators: make(map[protoreflect.FullName][]Validator),
+	}
+}
+
+// AddDefaulter registers a defaulter for the messages of the given type.
+// The defaulters of a type run in the order they were registered.
+func (r *Registry) AddDefaulter(name protoreflect.FullName, fn Defaulter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaulters[name] = append(r.defaulters[name], fn)
+}
+
+// AddValidator registers a validator for the messages of the given type.
+func (r *Registry) AddValidator(name protoreflect.FullName, fn Validator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validators[name] = append(r.validators[name], fn)
+}
+
+// RegisterDefaulter registers a defaulter for the messages of type T.
+func RegisterDefaulter[T any, PT typed.Message[T]](r *Registry, fn func(ctx context.Context, m PT) error) {
+	var z PT
+	r.AddDefaulter(z.ProtoReflect().Descriptor().FullName(), typedFunc(fn))
+}
+
+// RegisterValidator registers a validator for the messages of type T.
+func RegisterValidator[T any, PT typed.Message[T]](r *Registry, fn func(ctx context.Context, m PT) error) {
+	var z PT
+	r.AddValidator(z.ProtoReflect().Descriptor().FullName(), typedFunc(fn))
+}
+
+// typedFunc adapts fn to the messages of another Go type than PT, e.g. the dynamic messages
+// resolved by the interceptors, by converting them to PT and back.
+func typedFunc[T any, PT typed.Message[T]](fn func(ctx context.Context, m PT) error) func(ctx context.Context, m proto.Message) error {
+	return func(ctx context.Context, m proto.Message) error {
+		if v, ok := m.(PT); ok {
+			return fn(ctx, v)
+		}
+		b, err := proto.Marshal(m)
+		if err != nil {
+			return err
+		}
+		var v PT = new(T)
+		if err := proto.Unmarshal(b, v); err != nil {
+			return err
+		}
+		if err := fn(ctx, v); err != nil {
+			return err
+		}
+		if b, err = proto.Marshal(v); err != nil {
+			return err
+		}
+		proto.Reset(m)
+		return proto.Unmarshal(b, m)
+	}
+}
+
+// has reports whether defaulters or validators are registered for the messages of the given type.
+func (r *Registry) has(name protoreflect.FullName) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.defaulters[name]) != 0 || len(r.validators[name]) != 0
+}
+
+// Default runs the defaulters of the message's type on m. It stops at the first failing defaulter.
+func (r *Registry) Default(ctx context.Context, m proto.Message) error {
+	r.mu.RLock()
+	defaulters := r.defaulters[m.ProtoReflect().Descriptor().FullName()]
+	r.mu.RUnlock()
+	for _, fn := range defaulters {
+		if err := fn(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate runs all the validators of the message's type and the rules on m, and returns
+// a *ValidationError with all their violations, or nil if m is valid.
+func (r *Registry) Validate(ctx context.Context, m proto.Message) error {
+	name := m.ProtoReflect().Descriptor().FullName()
+	r.mu.RLock()
+	validators := r.validators[name]
+	r.mu.RUnlock()
+	verr := &ValidationError{Type: string(name)}
+	for _, fn := range validators {
+		verr.add(fn(ctx, m))
+	}
+	if r.opts.Rules != nil {
+		verr.add(r.opts.Rules(m))
+	}
+	if len(verr.Violations) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// Admit runs the defaulters and then the validators on m.
+func (r *Registry) Admit(ctx context.Context, m proto.Message) error {
+	if err := r.Default(ctx, m); err != nil {
+		return err
+	}
+	return r.Validate(ctx, m)
+}
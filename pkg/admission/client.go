@@ -0,0 +1,63 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+
+	"go.linka.cloud/protodb"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewClient returns a client running the defaulters and the validators of the registry on the
+// messages set, in or outside a transaction. The invalid messages are rejected with a *ValidationError.
+// The defaulters are applied to a copy of the messages, the messages passed to Set are not modified.
+func NewClient(db protodb.Client, r *Registry) protodb.Client {
+	return &client{Client: db, r: r}
+}
+
+type client struct {
+	protodb.Client
+	r *Registry
+}
+
+func (c *client) Set(ctx context.Context, m proto.Message, opts ...protodb.SetOption) (proto.Message, error) {
+	m = proto.Clone(m)
+	if err := c.r.Admit(ctx, m); err != nil {
+		return nil, err
+	}
+	return c.Client.Set(ctx, m, opts...)
+}
+
+func (c *client) Tx(ctx context.Context, opts ...protodb.TxOption) (protodb.Tx, error) {
+	tx, err := c.Client.Tx(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, r: c.r}, nil
+}
+
+type txn struct {
+	protodb.Tx
+	r *Registry
+}
+
+func (t *txn) Set(ctx context.Context, m proto.Message, opts ...protodb.SetOption) (proto.Message, error) {
+	m = proto.Clone(m)
+	if err := t.r.Admit(ctx, m); err != nil {
+		return nil, err
+	}
+	return t.Tx.Set(ctx, m, opts...)
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Violation is a rule violated by a message.
+type Violation struct {
+	// Field is the path of the invalid field, e.g. spec.replicas.
+	// It is empty if the violation is about the whole message.
+	Field string
+	// Description describes why the field is invalid.
+	Description string
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Description
+	}
+	return v.Field + ": " + v.Description
+}
+
+// ValidationError is returned when a message is rejected by its validators.
+type ValidationError struct {
+	// Type is the full name of the message type.
+	Type string
+	// Violations are the rules violated by the message.
+	Violations []Violation
+}
+
+// Invalid returns a *ValidationError reporting that the field is invalid.
+func Invalid(field, description string) *ValidationError {
+	return &ValidationError{Violations: []Violation{{Field: field, Description: description}}}
+}
+
+// Invalidf is like Invalid with a formatted description.
+func Invalidf(field, format string, args ...any) *ValidationError {
+	return Invalid(field, fmt.Sprintf(format, args...))
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Type != "" {
+		b.WriteString(e.Type)
+		b.WriteString(" ")
+	}
+	b.WriteString("is invalid")
+	for i, v := range e.Violations {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+func (e *ValidationError) add(err error) {
+	if err == nil {
+		return
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		e.Violations = append(e.Violations, verr.Violations...)
+		return
+	}
+	e.Violations = append(e.Violations, Violation{Description: err.Error()})
+}
+
+// GRPCStatus returns an InvalidArgument status with the violations as errdetails.BadRequest details,
+// so that the error is kept when returned by a gRPC server.
+func (e *ValidationError) GRPCStatus() *status.Status {
+	s := status.New(codes.InvalidArgument, e.Error())
+	br := &errdetails.BadRequest{}
+	for _, v := range e.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	if ds, err := s.WithDetails(br); err == nil {
+		return ds
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// setRequest is the full name of the protodb set request.
+const setRequest protoreflect.FullName = "linka.cloud.protodb.SetRequest"
+
+// InterceptorOpts contains the options of the admission interceptors.
+type InterceptorOpts struct {
+	// Resolver resolves the types of the messages written. Defaults to protoregistry.GlobalTypes.
+	// The messages whose type cannot be resolved are rejected with a FailedPrecondition status
+	// if defaulters or validators are registered for their type, and written without being admitted otherwise.
+	Resolver interface {
+		protoregistry.MessageTypeResolver
+		protoregistry.ExtensionTypeResolver
+	}
+	// IsWrite reports whether a request message carries a write, whose google.protobuf.Any fields
+	// contain the messages to admit. Defaults to the protodb set requests, i.e. the linka.cloud.protodb.SetRequest
+	// messages, sent directly or nested in another request, e.g. a transaction request.
+	IsWrite func(m protoreflect.Message) bool
+}
+
+// InterceptorOpt allows to configure the admission interceptors.
+type InterceptorOpt func(*InterceptorOpts)
+
+// WithResolver sets the resolver of the types of the messages written.
+func WithResolver(r interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}) InterceptorOpt {
+	return func(o *InterceptorOpts) {
+		o.Resolver = r
+	}
+}
+
+// WithIsWrite sets the function reporting whether a request message carries a write.
+func WithIsWrite(fn func(m protoreflect.Message) bool) InterceptorOpt {
+	return func(o *InterceptorOpts) {
+		o.IsWrite = fn
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor running the defaulters and the validators of the registry
+// on the messages written through the protodb gRPC server, so that they are enforced for all the remote clients.
+// The defaulted messages replace the ones sent by the client, and the invalid ones are rejected with an
+// InvalidArgument status detailing the violations.
+//
+// The interceptors are standard gRPC interceptors, they can be installed with grpc.ChainUnaryInterceptor
+// or with the interceptor options of a grpc-toolkit service.
+func UnaryServerInterceptor(r *Registry, o ...InterceptorOpt) grpc.UnaryServerInterceptor {
+	a := newAdmitter(r, o...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if m, ok := req.(proto.Message); ok {
+			if err := a.admit(ctx, m.ProtoReflect()); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor admitting the messages written in the streams,
+// e.g. the protodb transactions, see UnaryServerInterceptor.
+// An invalid write fails the reception of the request, which aborts the transaction.
+func StreamServerInterceptor(r *Registry, o ...InterceptorOpt) grpc.StreamServerInterceptor {
+	a := newAdmitter(r, o...)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &stream{ServerStream: ss, a: a})
+	}
+}
+
+type stream struct {
+	grpc.ServerStream
+	a *admitter
+}
+
+func (s *stream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if pm, ok := m.(proto.Message); ok {
+		return s.a.admit(s.Context(), pm.ProtoReflect())
+	}
+	return nil
+}
+
+type admitter struct {
+	r    *Registry
+	opts InterceptorOpts
+}
+
+func newAdmitter(r *Registry, o ...InterceptorOpt) *admitter {
+	opts := InterceptorOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = protoregistry.GlobalTypes
+	}
+	if opts.IsWrite == nil {
+		opts.IsWrite = func(m protoreflect.Message) bool {
+			return m.Descriptor().FullName() == setRequest
+		}
+	}
+	return &admitter{r: r, opts: opts}
+}
+
+// admit admits the messages of the writes found in m, replacing them with their defaulted version.
+func (a *admitter) admit(ctx context.Context, m protoreflect.Message) error {
+	if a.opts.IsWrite(m) {
+		return a.admitWrite(ctx, m)
+	}
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil || fd.IsMap() {
+			return true
+		}
+		if fd.IsList() {
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = a.admit(ctx, v.List().Get(i).Message())
+			}
+			return err == nil
+		}
+		err = a.admit(ctx, v.Message())
+		return err == nil
+	})
+	return err
+}
+
+func (a *admitter) admitWrite(ctx context.Context, m protoreflect.Message) error {
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() || fd.Message().FullName() != "google.protobuf.Any" {
+			return true
+		}
+		payload, ok := v.Message().Interface().(*anypb.Any)
+		if !ok {
+			return true
+		}
+		err = a.admitAny(ctx, payload)
+		return err == nil
+	})
+	return err
+}
+
+func (a *admitter) admitAny(ctx context.Context, payload *anypb.Any) error {
+	m, err := anypb.UnmarshalNew(payload, proto.UnmarshalOptions{Resolver: a.opts.Resolver})
+	if err != nil {
+		// the unknown types cannot be admitted, only let them through if they have no admission rules
+		if name := payload.MessageName(); a.r.has(name) {
+			return status.Errorf(codes.FailedPrecondition, "%s cannot be admitted: %v", name, err)
+		}
+		return nil
+	}
+	if err := a.r.Admit(ctx, m); err != nil {
+		return err
+	}
+	// keep the type url sent by the client
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	payload.Value = b
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codec provides the encoding of the controllers requests, used to store them in protodb.
+package codec
+
+import (
+	"encoding/json"
+)
+
+// Codec encodes and decodes requests so that they can be stored.
+// Encoding the same request must always produce the same bytes.
+type Codec[T comparable] interface {
+	Marshal(item T) ([]byte, error)
+	Unmarshal(b []byte) (T, error)
+}
+
+// JSON is a Codec using the encoding/json package.
+type JSON[T comparable] struct{}
+
+func (JSON[T]) Marshal(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (JSON[T]) Unmarshal(b []byte) (T, error) {
+	var item T
+	err := json.Unmarshal(b, &item)
+	return item, err
+}
@@ -22,11 +22,20 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.linka.cloud/grpc-toolkit/logger"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/client-go/util/workqueue"
 
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/deadletter"
+	"go.linka.cloud/protodb-controller/pkg/dryrun"
 	"go.linka.cloud/protodb-controller/pkg/internal/controller"
+	ctrlmetrics "go.linka.cloud/protodb-controller/pkg/internal/controller/metrics"
+	"go.linka.cloud/protodb-controller/pkg/lease"
+	"go.linka.cloud/protodb-controller/pkg/metrics"
 	"go.linka.cloud/protodb-controller/pkg/reconcile"
+	"go.linka.cloud/protodb-controller/pkg/sharding"
 	"go.linka.cloud/protodb-controller/pkg/source"
 )
 
@@ -39,8 +48,13 @@ type TypedOptions[request comparable] struct {
 	SkipNameValidation *bool
 
 	// MaxConcurrentReconciles is the maximum number of concurrent Reconciles which can be run. Defaults to 1.
+	// It can be changed at runtime with SetWorkers.
 	MaxConcurrentReconciles int
 
+	// Autoscale, if set, scales the number of workers between Autoscale.MinWorkers and Autoscale.MaxWorkers
+	// based on the queue depth and the reconcile latency. MaxConcurrentReconciles is then the initial number of workers.
+	Autoscale *Autoscale
+
 	// CacheSyncTimeout refers to the time limit set to wait for syncing caches.
 	// Defaults to 2 minutes if not set.
 	CacheSyncTimeout time.Duration
@@ -77,6 +91,57 @@ type TypedOptions[request comparable] struct {
 	// LogConstructor is used to construct a logger used for this controller and passed
 	// to each reconciliation via the context field.
 	LogConstructor func(request *request) logr.Logger
+
+	// MaxRetries is the number of times a failing request is retried before being moved to
+	// the DeadLetter store, or dropped if DeadLetter is not set. Defaults to 0, which means retrying forever.
+	// Only the failed reconciles are counted: the requeues requested by the reconciler and
+	// the failures to acquire the Locker's lock are not counted as retries.
+	MaxRetries int
+
+	// DeadLetter stores the requests which failed with a terminal error or more than MaxRetries times,
+	// they can then be inspected with the store and reconciled again with Redrive.
+	// If not set, such requests are dropped.
+	DeadLetter deadletter.Store[request]
+
+	// Sharder distributes the requests across the replicas running the controller: each replica
+	// only enqueues and reconciles the requests it owns. NeedLeaderElection should be false
+	// when using a Sharder, so that all the replicas are active.
+	Sharder *sharding.Sharder[request]
+
+	// Locker is used to acquire a cluster-wide lock on each request before reconciling it, so that
+	// all the replicas can run the controller while a request is never reconciled concurrently.
+	// Requests locked by another replica are requeued once the lock may be available.
+	// NeedLeaderElection should be false when using a Locker.
+	Locker lease.Locker[request]
+
+	// MetricsProvider creates the controller's metrics, e.g. to report them to a dedicated registry
+	// created with NewPrometheusMetricsProvider or to another metrics system.
+	// Defaults to the prometheus metrics registered in metrics.Registry.
+	// The queue metrics are reported by the queue's workqueue.MetricsProvider.
+	MetricsProvider metrics.MetricsProvider
+
+	// TracerProvider, if set, enables the OpenTelemetry tracing of the reconciles: a span is recorded for each
+	// reconcile, linked to the spans recorded when the request was added to the queue by a source passing its
+	// context with priorityqueue.ContextAdder.
+	TracerProvider trace.TracerProvider
+
+	// DryRun, if set, records the results of the reconciles in the report of the dry-run client,
+	// which is logged when the controller stops. The reconciler must use the dry-run client
+	// for its writes to be logged instead of applied.
+	DryRun *dryrun.Client
+}
+
+// Status is the state of a controller.
+type Status = controller.Status
+
+// Autoscale configures the adaptive scaling of the workers.
+type Autoscale = controller.Autoscale
+
+// NewPrometheusMetricsProvider returns a metrics.MetricsProvider reporting the controllers metrics
+// to prometheus vectors registered in reg, allowing independent controllers to use their own registry.
+// The returned provider also implements metrics.WebhookMetricsProvider, see source.WithWebhookMetricsProvider.
+func NewPrometheusMetricsProvider(reg prometheus.Registerer) (metrics.MetricsProvider, error) {
+	return ctrlmetrics.NewPrometheusProvider(reg)
 }
 
 // TypedController implements an API.
@@ -93,6 +158,40 @@ type TypedController[request comparable] interface {
 
 	// GetLogger returns this controller logger prefilled with basic information.
 	GetLogger() logr.Logger
+
+	// Synced returns true once the sources are synced and the workers started.
+	Synced() bool
+
+	// Redrive removes the requests from the dead-letter store and enqueues them again.
+	Redrive(ctx context.Context, reqs ...request) error
+
+	// Status returns the current state of the controller.
+	Status() Status
+
+	// Pause stops the workers from dequeuing requests. The sources keep adding requests to the queue,
+	// where they are deduplicated, until the controller is resumed.
+	// Requests being reconciled when pausing are not interrupted.
+	Pause()
+
+	// Resume resumes a paused controller, the workers process the requests accumulated in the queue.
+	Resume()
+
+	// Paused returns true while the controller is paused.
+	Paused() bool
+
+	// SetWorkers sets the number of workers. It fails if autoscaling is enabled.
+	// When decreasing, the workers in excess return immediately if idle, or once done with their current request.
+	SetWorkers(n int) error
+
+	// Inspect returns the content of the queue.
+	Inspect() ([]priorityqueue.ItemInfo[request], error)
+
+	// Enqueue adds the requests to the queue.
+	Enqueue(reqs ...request) error
+
+	// Forget removes the requests waiting in the queue and resets their retries.
+	// Requests being reconciled are not interrupted.
+	Forget(reqs ...request) error
 }
 
 // NewTypedUnmanaged returns a new typed controller without adding it to the manager.
@@ -126,6 +225,14 @@ func NewTypedUnmanaged[request comparable](name string, options TypedOptions[req
 		options.MaxConcurrentReconciles = 1
 	}
 
+	if options.Autoscale != nil {
+		a := *options.Autoscale
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+		options.Autoscale = &a
+	}
+
 	if options.CacheSyncTimeout == 0 {
 
 		options.CacheSyncTimeout = 2 * time.Minute
@@ -143,17 +250,29 @@ func NewTypedUnmanaged[request comparable](name string, options TypedOptions[req
 		}
 	}
 
+	if options.DryRun != nil {
+		options.Reconciler = dryrun.Reconciler(options.DryRun, options.Reconciler)
+	}
+
 	// Create controller with dependencies set
 	return &controller.Controller[request]{
 		Do:                      options.Reconciler,
 		RateLimiter:             options.RateLimiter,
 		NewQueue:                options.NewQueue,
 		MaxConcurrentReconciles: options.MaxConcurrentReconciles,
+		Autoscale:               options.Autoscale,
 		CacheSyncTimeout:        options.CacheSyncTimeout,
 		Name:                    name,
 		LogConstructor:          options.LogConstructor,
 		RecoverPanic:            options.RecoverPanic,
 		LeaderElected:           options.NeedLeaderElection,
+		MaxRetries:              options.MaxRetries,
+		DeadLetter:              options.DeadLetter,
+		Sharder:                 options.Sharder,
+		Locker:                  options.Locker,
+		TracerProvider:          options.TracerProvider,
+		MetricsProvider:         options.MetricsProvider,
+		DryRun:                  options.DryRun,
 	}, nil
 }
 
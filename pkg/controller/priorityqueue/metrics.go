@@ -1,35 +1,49 @@
 package priorityqueue
 
 import (
+	"slices"
 	"sync"
 	"time"
 
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
+
+	"go.linka.cloud/protodb-controller/pkg/internal/metrics"
 )
 
 // This file is mostly a copy of unexported code from
 // https://github.com/kubernetes/kubernetes/blob/1d8828ce707ed9dd7a6a9756385419cce1d202ac/staging/src/k8s.io/client-go/util/workqueue/metrics.go
 //
-// The only two differences are the addition of mapLock in defaultQueueMetrics and converging retryMetrics into queueMetrics.
+// The differences are the addition of mapLock in defaultQueueMetrics, converging retryMetrics into queueMetrics
+// and reporting the depth, adds and latency metrics by priority.
 
 type queueMetrics[T comparable] interface {
-	add(item T)
-	get(item T)
+	add(item T, priority int)
+	get(item T, priority int)
+	remove(item T, priority int)
+	updateDepthWithPriority(oldPriority, newPriority int)
 	done(item T)
 	updateUnfinishedWork()
 	retry()
 }
 
-func newQueueMetrics[T comparable](mp workqueue.MetricsProvider, name string, clock clock.Clock) queueMetrics[T] {
+func newQueueMetrics[T comparable](mp workqueue.MetricsProvider, name string, clock clock.Clock, bucket func(priority int) int) queueMetrics[T] {
 	if len(name) == 0 {
 		return noMetrics[T]{}
 	}
+	if bucket == nil {
+		bucket = func(priority int) int { return priority }
+	}
+	pmp, ok := mp.(metrics.MetricsProviderWithPriority)
+	if !ok {
+		pmp = withoutPriority{mp}
+	}
 	return &defaultQueueMetrics[T]{
 		clock:                   clock,
-		depth:                   mp.NewDepthMetric(name),
-		adds:                    mp.NewAddsMetric(name),
-		latency:                 mp.NewLatencyMetric(name),
+		bucket:                  bucket,
+		depth:                   pmp.NewDepthMetricWithPriority(name),
+		adds:                    pmp.NewAddsMetricWithPriority(name),
+		latency:                 pmp.NewLatencyMetricWithPriority(name),
 		workDuration:            mp.NewWorkDurationMetric(name),
 		unfinishedWorkSeconds:   mp.NewUnfinishedWorkSecondsMetric(name),
 		longestRunningProcessor: mp.NewLongestRunningProcessorSecondsMetric(name),
@@ -43,12 +57,15 @@ func newQueueMetrics[T comparable](mp workqueue.MetricsProvider, name string, cl
 type defaultQueueMetrics[T comparable] struct {
 	clock clock.Clock
 
+	// bucket maps an item priority to the priority reported by the metrics
+	bucket func(priority int) int
+
 	// current depth of a workqueue
-	depth workqueue.GaugeMetric
+	depth metrics.DepthMetricWithPriority
 	// total number of adds handled by a workqueue
-	adds workqueue.CounterMetric
+	adds metrics.CounterMetricWithPriority
 	// how long an item stays in a workqueue
-	latency workqueue.HistogramMetric
+	latency metrics.HistogramMetricWithPriority
 	// how long processing an item from a workqueue takes
 	workDuration workqueue.HistogramMetric
 
@@ -64,13 +81,13 @@ type defaultQueueMetrics[T comparable] struct {
 }
 
 // add is called for ready items only
-func (m *defaultQueueMetrics[T]) add(item T) {
+func (m *defaultQueueMetrics[T]) add(item T, priority int) {
 	if m == nil {
 		return
 	}
 
-	m.adds.Inc()
-	m.depth.Inc()
+	m.adds.Inc(m.bucket(priority))
+	m.depth.Inc(m.bucket(priority))
 
 	m.mapLock.Lock()
 	defer m.mapLock.Unlock()
@@ -80,23 +97,49 @@ func (m *defaultQueueMetrics[T]) add(item T) {
 	}
 }
 
-func (m *defaultQueueMetrics[T]) get(item T) {
+func (m *defaultQueueMetrics[T]) get(item T, priority int) {
 	if m == nil {
 		return
 	}
 
-	m.depth.Dec()
+	m.depth.Dec(m.bucket(priority))
 
 	m.mapLock.Lock()
 	defer m.mapLock.Unlock()
 
 	m.processingStartTimes[item] = m.clock.Now()
 	if startTime, exists := m.addTimes[item]; exists {
-		m.latency.Observe(m.sinceInSeconds(startTime))
+		m.latency.Observe(m.bucket(priority), m.sinceInSeconds(startTime))
 		delete(m.addTimes, item)
 	}
 }
 
+// remove is called for ready items removed from the queue without being handed out.
+func (m *defaultQueueMetrics[T]) remove(item T, priority int) {
+	if m == nil {
+		return
+	}
+
+	m.depth.Dec(m.bucket(priority))
+
+	m.mapLock.Lock()
+	defer m.mapLock.Unlock()
+
+	delete(m.addTimes, item)
+}
+
+// updateDepthWithPriority moves a ready item from the depth of its old priority to the new one.
+func (m *defaultQueueMetrics[T]) updateDepthWithPriority(oldPriority, newPriority int) {
+	if m == nil {
+		return
+	}
+
+	if o, n := m.bucket(oldPriority), m.bucket(newPriority); o != n {
+		m.depth.Dec(o)
+		m.depth.Inc(n)
+	}
+}
+
 func (m *defaultQueueMetrics[T]) done(item T) {
 	if m == nil {
 		return
@@ -139,8 +182,69 @@ func (m *defaultQueueMetrics[T]) retry() {
 
 type noMetrics[T any] struct{}
 
-func (noMetrics[T]) add(item T)            {}
-func (noMetrics[T]) get(item T)            {}
-func (noMetrics[T]) done(item T)           {}
-func (noMetrics[T]) updateUnfinishedWork() {}
-func (noMetrics[T]) retry()                {}
+func (noMetrics[T]) add(item T, priority int)                             {}
+func (noMetrics[T]) get(item T, priority int)                             {}
+func (noMetrics[T]) remove(item T, priority int)                          {}
+func (noMetrics[T]) updateDepthWithPriority(oldPriority, newPriority int) {}
+func (noMetrics[T]) done(item T)                                          {}
+func (noMetrics[T]) updateUnfinishedWork()                                {}
+func (noMetrics[T]) retry()                                               {}
+
+// withoutPriority adapts a workqueue.MetricsProvider that does not know about
+// priorities, all the priorities are reported in the same metrics.
+type withoutPriority struct {
+	workqueue.MetricsProvider
+}
+
+func (p withoutPriority) NewDepthMetricWithPriority(name string) metrics.DepthMetricWithPriority {
+	return depthWithoutPriority{p.NewDepthMetric(name)}
+}
+
+func (p withoutPriority) NewAddsMetricWithPriority(name string) metrics.CounterMetricWithPriority {
+	return addsWithoutPriority{p.NewAddsMetric(name)}
+}
+
+func (p withoutPriority) NewLatencyMetricWithPriority(name string) metrics.HistogramMetricWithPriority {
+	return latencyWithoutPriority{p.NewLatencyMetric(name)}
+}
+
+type depthWithoutPriority struct {
+	workqueue.GaugeMetric
+}
+
+func (d depthWithoutPriority) Inc(int) { d.GaugeMetric.Inc() }
+func (d depthWithoutPriority) Dec(int) { d.GaugeMetric.Dec() }
+
+type addsWithoutPriority struct {
+	workqueue.CounterMetric
+}
+
+func (a addsWithoutPriority) Inc(int) { a.CounterMetric.Inc() }
+
+type latencyWithoutPriority struct {
+	workqueue.HistogramMetric
+}
+
+func (l latencyWithoutPriority) Observe(_ int, v float64) { l.HistogramMetric.Observe(v) }
+
+// PriorityBuckets returns a function usable as Opts.MetricsPriorityBucket which reports
+// each priority as the greatest of the given bounds lower or equal to it. Priorities lower
+// than all the bounds are reported as the lowest bound.
+func PriorityBuckets(bounds ...int) func(priority int) int {
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	return func(priority int) int {
+		if len(bounds) == 0 {
+			return priority
+		}
+		i, found := slices.BinarySearch(bounds, priority)
+		switch {
+		case found:
+			return bounds[i]
+		case i == 0:
+			return bounds[0]
+		default:
+			return bounds[i-1]
+		}
+	}
+}
@@ -0,0 +1,466 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package persistent provides a priorityqueue.PriorityQueue which stores its items in protodb,
+// so that pending items, their priority, their delay and their rate limiter failures
+// survive a restart of the controller.
+package persistent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"go.linka.cloud/protofilters/filters"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+
+	"go.linka.cloud/protodb-controller/pkg/codec"
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/pb"
+)
+
+// Opts contains the options for a persistent PriorityQueue.
+type Opts[T comparable] struct {
+	// Codec encodes the items to store them. Defaults to codec.JSON.
+	Codec codec.Codec[T]
+	// RateLimiter is being used when AddRateLimited is called. Defaults to a per-item exponential backoff
+	// limiter with an initial delay of five milliseconds and a max delay of 1000 seconds.
+	// The failures are restored by calling When on the rate limiter as many times as
+	// they were recorded, so it should be a per-item rate limiter.
+	RateLimiter workqueue.TypedRateLimiter[T]
+	// QueueOpts are the options of the underlying in-memory priorityqueue.
+	QueueOpts []priorityqueue.Opt[T]
+	// Timeout is the timeout of the database operations. Defaults to 10 seconds.
+	Timeout time.Duration
+	Log     logr.Logger
+}
+
+// Opt allows to configure a persistent PriorityQueue.
+type Opt[T comparable] func(*Opts[T])
+
+// NewQueue returns a function usable as the controller's NewQueue option.
+// The controller fails to start if the queue could not be restored.
+func NewQueue[T comparable](db protodb.Client, o ...Opt[T]) func(controllerName string, rateLimiter workqueue.TypedRateLimiter[T]) workqueue.TypedRateLimitingInterface[T] {
+	return func(controllerName string, rateLimiter workqueue.TypedRateLimiter[T]) workqueue.TypedRateLimitingInterface[T] {
+		o := append([]Opt[T]{func(o *Opts[T]) {
+			o.RateLimiter = rateLimiter
+		}}, o...)
+		q, _ := New[T](db, controllerName, o...)
+		return q
+	}
+}
+
+// New constructs a new persistent PriorityQueue and restores the items stored in db
+// under the given name. The returned queue is usable even if an error is returned,
+// it then only contains the items that could be restored.
+//
+// The changes are written to db asynchronously, in batches, so that adding and handing out
+// the items does not wait for the database. They are flushed when the queue is shut down.
+func New[T comparable](db protodb.Client, name string, o ...Opt[T]) (priorityqueue.PriorityQueue[T], error) {
+	opts := &Opts[T]{}
+	for _, f := range o {
+		f(opts)
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.JSON[T]{}
+	}
+	if opts.RateLimiter == nil {
+		opts.RateLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[T](5*time.Millisecond, 1000*time.Second)
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	// the delays are stored and restored with the queue's clock
+	now := time.Now
+	qo := append(slices.Clone(opts.QueueOpts), func(o *priorityqueue.Opts[T]) {
+		o.RateLimiter = opts.RateLimiter
+		if o.Log.GetSink() == nil {
+			o.Log = opts.Log
+		}
+		if o.Clock != nil {
+			now = o.Clock.Now
+		}
+	})
+	pq := priorityqueue.New[T](name, qo...)
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &queue[T]{
+		PriorityQueue: pq,
+		db:            typed.NewStore[pb.QueueItem](db),
+		name:          name,
+		codec:         opts.Codec,
+		rateLimiter:   opts.RateLimiter,
+		timeout:       opts.Timeout,
+		log:           opts.Log.WithValues("queue", name),
+		items:         map[T]*state{},
+		dirty:         map[T]struct{}{},
+		flush:         make(chan struct{}, 1),
+		flushed:       make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		now:           now,
+	}
+	q.restoreErr = q.restore()
+	go q.write()
+	return q, q.restoreErr
+}
+
+type queue[T comparable] struct {
+	priorityqueue.PriorityQueue[T]
+
+	db          typed.Store[pb.QueueItem, *pb.QueueItem]
+	name        string
+	codec       codec.Codec[T]
+	rateLimiter workqueue.TypedRateLimiter[T]
+	timeout     time.Duration
+	log         logr.Logger
+
+	// lock has to be acquired for any access to items and dirty.
+	lock sync.Mutex
+	// items mirrors the stored state of the items that are either waiting in the queue
+	// or handed out and not yet done.
+	items map[T]*state
+	// dirty holds the items whose state changed since it was last written,
+	// they are stored if they are in items and deleted otherwise.
+	dirty map[T]struct{}
+	// flush signals the writer that items are dirty, flushed is closed once
+	// the writer wrote the last changes after the shutdown.
+	flush   chan struct{}
+	flushed chan struct{}
+	// closed is true once flush is closed, the changes are then no longer written.
+	closed bool
+
+	restoreErr error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Configurable for testing
+	now func() time.Time
+}
+
+type state struct {
+	priority int
+	readyAt  *time.Time
+	failures int
+	// pending is true when the item is waiting in the queue, it is false
+	// when the item was handed out and not added again since.
+	pending bool
+	// processing is true when the item was handed out and is not done yet.
+	processing bool
+}
+
+func (w *queue[T]) restore() error {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
+	defer cancel()
+	if err := w.db.Raw().Register(ctx, pb.File_pb_queue_proto); err != nil {
+		return err
+	}
+	rs, _, err := w.db.Get(ctx, &pb.QueueItem{}, protodb.WithFilter(filters.Where("queue").StringEquals(w.name)))
+	if err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, v := range rs {
+		key, err := w.codec.Unmarshal(v.Key)
+		if err != nil {
+			w.log.Error(err, "Failed to decode stored item, dropping it", "id", v.ID)
+			if err := w.db.Delete(ctx, v); err != nil {
+				w.log.Error(err, "Failed to delete stored item", "id", v.ID)
+			}
+			continue
+		}
+		for range v.Failures {
+			w.rateLimiter.When(key)
+		}
+		s := &state{priority: int(v.Priority), failures: int(v.Failures), pending: true}
+		var after time.Duration
+		if v.ReadyAt != nil {
+			s.readyAt = ptr.To(v.ReadyAt.AsTime())
+			after = s.readyAt.Sub(w.now())
+		}
+		w.items[key] = s
+		w.PriorityQueue.AddWithOpts(priorityqueue.AddOpts{After: after, Priority: s.priority}, key)
+	}
+	w.log.V(1).Info("Restored persistent queue", "items", len(rs))
+	return nil
+}
+
+// Err returns the error which occurred while restoring the queue, if any.
+func (w *queue[T]) Err() error {
+	return w.restoreErr
+}
+
+func (w *queue[T]) AddWithOpts(o priorityqueue.AddOpts, items ...T) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, key := range items {
+		// Compute the rate limited delay here instead of letting the underlying queue do it,
+		// so that we know when the item will be ready.
+		after := o.After
+		if o.RateLimited {
+			rlAfter := w.rateLimiter.When(key)
+			if after == 0 || rlAfter < after {
+				after = rlAfter
+			}
+		}
+		var readyAt *time.Time
+		if after > 0 {
+			readyAt = ptr.To(w.now().Add(after))
+		}
+
+		// Mirror the underlying queue de-duplication: max of the priorities
+		// and min of the ready times.
+		s, ok := w.items[key]
+		switch {
+		case !ok:
+			s = &state{priority: o.Priority, readyAt: readyAt}
+			w.items[key] = s
+		case !s.pending:
+			s.priority, s.readyAt = o.Priority, readyAt
+		default:
+			s.priority = max(s.priority, o.Priority)
+			if s.readyAt != nil && (readyAt == nil || readyAt.Before(*s.readyAt)) {
+				s.readyAt = readyAt
+			}
+		}
+		s.pending = true
+		s.failures = w.rateLimiter.NumRequeues(key)
+		w.markDirty(key)
+
+		w.PriorityQueue.AddWithOpts(priorityqueue.AddOpts{After: after, Priority: o.Priority}, key)
+	}
+}
+
+func (w *queue[T]) Add(item T) {
+	w.AddWithOpts(priorityqueue.AddOpts{}, item)
+}
+
+func (w *queue[T]) AddAfter(item T, after time.Duration) {
+	w.AddWithOpts(priorityqueue.AddOpts{After: after}, item)
+}
+
+func (w *queue[T]) AddRateLimited(item T) {
+	w.AddWithOpts(priorityqueue.AddOpts{RateLimited: true}, item)
+}
+
+func (w *queue[T]) GetWithPriority() (T, int, bool) {
+	item, priority, shutdown := w.PriorityQueue.GetWithPriority()
+	if shutdown {
+		return item, priority, shutdown
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	// The stored item is kept until Done, so that it is handed out
+	// again after a restart if the processing did not complete.
+	if s, ok := w.items[item]; ok {
+		s.pending = false
+		s.processing = true
+	}
+	return item, priority, shutdown
+}
+
+func (w *queue[T]) Get() (T, bool) {
+	item, _, shutdown := w.GetWithPriority()
+	return item, shutdown
+}
+
+func (w *queue[T]) Forget(item T) {
+	w.PriorityQueue.Forget(item)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if s, ok := w.items[item]; ok && s.failures != 0 {
+		s.failures = 0
+		w.markDirty(item)
+	}
+}
+
+func (w *queue[T]) Done(item T) {
+	w.lock.Lock()
+	if s, ok := w.items[item]; ok {
+		s.processing = false
+		if !s.pending {
+			delete(w.items, item)
+			w.markDirty(item)
+		}
+	}
+	w.lock.Unlock()
+	w.PriorityQueue.Done(item)
+}
+
+func (w *queue[T]) Remove(items ...T) {
+	w.lock.Lock()
+	for _, key := range items {
+		s, ok := w.items[key]
+		if !ok || !s.pending {
+			continue
+		}
+		s.pending = false
+		// keep the stored item of items being processed, it is removed once done
+		if !s.processing {
+			delete(w.items, key)
+			w.markDirty(key)
+		}
+	}
+	w.lock.Unlock()
+	w.PriorityQueue.Remove(items...)
+}
+
+// ShutDown shuts down the queue and waits for the pending changes to be written, at most for Timeout.
+// The changes made afterwards, e.g. by the requests being processed, are not written: the stored items
+// of these requests are kept and they are handed out again after a restart.
+func (w *queue[T]) ShutDown() {
+	w.PriorityQueue.ShutDown()
+	w.lock.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.flush)
+	}
+	w.lock.Unlock()
+	select {
+	case <-w.flushed:
+	case <-time.After(w.timeout):
+		w.log.Info("Timed out writing the pending changes")
+	}
+	w.cancel()
+}
+
+func (w *queue[T]) ShutDownWithDrain() {
+	w.ShutDown()
+}
+
+// markDirty schedules the write of the item state, it must be called with the lock held.
+func (w *queue[T]) markDirty(key T) {
+	if w.closed {
+		return
+	}
+	w.dirty[key] = struct{}{}
+	select {
+	case w.flush <- struct{}{}:
+	default:
+	}
+}
+
+// write writes the dirty items until the queue is shut down.
+func (w *queue[T]) write() {
+	defer close(w.flushed)
+	for {
+		_, ok := <-w.flush
+		w.writeDirty()
+		if !ok {
+			return
+		}
+	}
+}
+
+// writeDirty writes the current state of the dirty items in a single transaction.
+// The items which could not be written are marked dirty again and retried on the next write.
+func (w *queue[T]) writeDirty() {
+	w.lock.Lock()
+	if len(w.dirty) == 0 {
+		w.lock.Unlock()
+		return
+	}
+	sets := make(map[T]*pb.QueueItem, len(w.dirty))
+	var deletes []T
+	for key := range w.dirty {
+		if s, ok := w.items[key]; ok {
+			sets[key] = w.message(key, s)
+		} else {
+			deletes = append(deletes, key)
+		}
+	}
+	clear(w.dirty)
+	w.lock.Unlock()
+
+	if err := w.commit(sets, deletes); err != nil {
+		w.log.Error(err, "Failed to write queue items", "items", len(sets)+len(deletes))
+		w.lock.Lock()
+		for key := range sets {
+			w.dirty[key] = struct{}{}
+		}
+		for _, key := range deletes {
+			w.dirty[key] = struct{}{}
+		}
+		w.lock.Unlock()
+	}
+}
+
+func (w *queue[T]) commit(sets map[T]*pb.QueueItem, deletes []T) error {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
+	defer cancel()
+	tx, err := w.db.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	for key, m := range sets {
+		if m == nil {
+			// the key could not be encoded, it was logged
+			delete(sets, key)
+			continue
+		}
+		if _, err := tx.Set(ctx, m); err != nil {
+			return err
+		}
+	}
+	for _, key := range deletes {
+		id, _, err := w.id(key)
+		if err != nil {
+			w.log.Error(err, "Failed to encode item", "item", key)
+			continue
+		}
+		if err := tx.Delete(ctx, &pb.QueueItem{ID: id}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// message returns the stored item, it must be called with the lock held.
+func (w *queue[T]) message(key T, s *state) *pb.QueueItem {
+	id, k, err := w.id(key)
+	if err != nil {
+		w.log.Error(err, "Failed to encode item", "item", key)
+		return nil
+	}
+	m := &pb.QueueItem{
+		ID:       id,
+		Queue:    w.name,
+		Key:      k,
+		Priority: int64(s.priority),
+		Failures: uint32(s.failures),
+	}
+	if s.readyAt != nil {
+		m.ReadyAt = timestamppb.New(*s.readyAt)
+	}
+	return m
+}
+
+func (w *queue[T]) id(key T) (string, []byte, error) {
+	b, err := w.codec.Marshal(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s/%s", w.name, base64.RawURLEncoding.EncodeToString(b)), b, nil
+}
@@ -1,6 +1,7 @@
 package priorityqueue
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,10 @@ type AddOpts struct {
 	After       time.Duration
 	RateLimited bool
 	Priority    int
+	// EventTime is the time of the event which caused the items to be added, e.g. the time
+	// a watch event was received. It is used by the ContextAdder queues of the controllers
+	// to measure the latency between the events and their reconcile. Zero means the time of the add.
+	EventTime time.Time
 }
 
 // PriorityQueue is a priority queue for a controller. It
@@ -30,6 +35,32 @@ type PriorityQueue[T comparable] interface {
 	workqueue.TypedRateLimitingInterface[T]
 	AddWithOpts(o AddOpts, Items ...T)
 	GetWithPriority() (item T, priority int, shutdown bool)
+	// Snapshot returns the items waiting in the queue in the order they will be handed
+	// out, followed by the items being processed which are not waiting.
+	Snapshot() []ItemInfo[T]
+	// Remove removes the items waiting in the queue. It does not affect
+	// the items being processed nor the rate limiter.
+	Remove(items ...T)
+}
+
+// ContextAdder is implemented by the queues using the context the items are added with,
+// e.g. to link the reconcile traces to the span the items were enqueued in.
+type ContextAdder[T comparable] interface {
+	AddWithContext(ctx context.Context, o AddOpts, items ...T)
+}
+
+// ItemInfo describes an item of the queue.
+type ItemInfo[T comparable] struct {
+	Key      T   `json:"key"`
+	Priority int `json:"priority"`
+	// ReadyAt is the time after which the item can be handed out, it is nil if it was added without delay.
+	ReadyAt *time.Time `json:"readyAt,omitempty"`
+	// Retries is the number of retries recorded by the rate limiter for the item.
+	Retries int `json:"retries"`
+	// Waiting is true when the item is waiting in the queue.
+	Waiting bool `json:"waiting"`
+	// Processing is true when the item was handed out and is not done yet.
+	Processing bool `json:"processing"`
 }
 
 // Opts contains the options for a PriorityQueue.
@@ -38,7 +69,23 @@ type Opts[T comparable] struct {
 	// limiter with an initial delay of five milliseconds and a max delay of 1000 seconds.
 	RateLimiter    workqueue.TypedRateLimiter[T]
 	MetricProvider workqueue.MetricsProvider
-	Log            logr.Logger
+	// MetricsPriorityBucket maps the priority of an item to the priority reported by the depth,
+	// adds and latency metrics. It allows to bound the metrics cardinality when many distinct
+	// priorities are used, see PriorityBuckets. Defaults to reporting the priority as is.
+	MetricsPriorityBucket func(priority int) int
+	// GroupFunc enables fair queuing across groups of items: the items with the same
+	// priority are handed out in a round-robin fashion across the groups returned by
+	// GroupFunc instead of in the order they were added. This prevents a group adding
+	// a large number of items from starving the others. Defaults to no grouping.
+	GroupFunc func(item T) string
+	// GroupWeight returns the weight of a group when GroupFunc is set, a group with a weight
+	// of 2 gets twice as many items handed out as a group with a weight of 1.
+	// Weights lower than 1 are treated as 1. Defaults to 1 for all groups.
+	GroupWeight func(group string) int
+	// Clock is used to compute when the items added with a delay are ready, allowing to
+	// step the time in tests. Defaults to the real clock.
+	Clock clock.Clock
+	Log   logr.Logger
 }
 
 // Opt allows to configure a PriorityQueue.
@@ -59,12 +106,16 @@ func New[T comparable](name string, o ...Opt[T]) PriorityQueue[T] {
 		opts.MetricProvider = metrics.WorkqueueMetricsProvider{}
 	}
 
+	if opts.Clock == nil {
+		opts.Clock = clock.RealClock{}
+	}
+
 	pq := &priorityqueue[T]{
 		log:         opts.Log,
 		items:       map[T]*item[T]{},
 		queue:       btree.NewG(32, less[T]),
 		becameReady: sets.Set[T]{},
-		metrics:     newQueueMetrics[T](opts.MetricProvider, name, clock.RealClock{}),
+		metrics:     newQueueMetrics[T](opts.MetricProvider, name, opts.Clock, opts.MetricsPriorityBucket),
 		// itemOrWaiterAdded indicates that an item or
 		// waiter was added. It must be buffered, because
 		// if we currently process items we can't tell
@@ -74,8 +125,11 @@ func New[T comparable](name string, o ...Opt[T]) PriorityQueue[T] {
 		locked:            sets.Set[T]{},
 		done:              make(chan struct{}),
 		get:               make(chan item[T]),
-		now:               time.Now,
-		tick:              time.Tick,
+		groupFunc:         opts.GroupFunc,
+		groupWeight:       opts.GroupWeight,
+		groups:            map[string]*group{},
+		now:               opts.Clock.Now,
+		tick:              opts.Clock.Tick,
 	}
 
 	go pq.spin()
@@ -123,6 +177,16 @@ type priorityqueue[T comparable] struct {
 	// if we can push items.
 	waiters atomic.Int64
 
+	// groupFunc and groupWeight configure the fair queuing across groups,
+	// see Opts.GroupFunc. groupFunc is nil if fair queuing is disabled.
+	groupFunc   func(T) string
+	groupWeight func(string) int
+	// groups holds the fair queuing state of the groups which have items
+	// in the queue. It must only be accessed with lock held.
+	groups map[string]*group
+	// virtualTime is the fairness tag of the last item handed out.
+	virtualTime float64
+
 	// Configurable for testing
 	now  func() time.Time
 	tick func(time.Duration) <-chan time.Time
@@ -153,10 +217,11 @@ func (w *priorityqueue[T]) AddWithOpts(o AddOpts, items ...T) {
 				Priority:     o.Priority,
 				ReadyAt:      readyAt,
 			}
+			w.enqueued(item)
 			w.items[key] = item
 			w.queue.ReplaceOrInsert(item)
 			if item.ReadyAt == nil {
-				w.metrics.add(key)
+				w.metrics.add(key, item.Priority)
 			}
 			w.addedCounter++
 			continue
@@ -166,12 +231,16 @@ func (w *priorityqueue[T]) AddWithOpts(o AddOpts, items ...T) {
 		// will affect the order - Just delete and re-add.
 		item, _ := w.queue.Delete(w.items[key])
 		if o.Priority > item.Priority {
+			// Only move the depth if the item was already counted in it.
+			if item.ReadyAt == nil || w.becameReady.Has(key) {
+				w.metrics.updateDepthWithPriority(item.Priority, o.Priority)
+			}
 			item.Priority = o.Priority
 		}
 
 		if item.ReadyAt != nil && (readyAt == nil || readyAt.Before(*item.ReadyAt)) {
 			if readyAt == nil && !w.becameReady.Has(key) {
-				w.metrics.add(key)
+				w.metrics.add(key, item.Priority)
 			}
 			item.ReadyAt = readyAt
 		}
@@ -223,7 +292,7 @@ func (w *priorityqueue[T]) spin() {
 						return false
 					}
 					if !w.becameReady.Has(item.Key) {
-						w.metrics.add(item.Key)
+						w.metrics.add(item.Key, item.Priority)
 						w.becameReady.Insert(item.Key)
 					}
 				}
@@ -239,7 +308,8 @@ func (w *priorityqueue[T]) spin() {
 					return true
 				}
 
-				w.metrics.get(item.Key)
+				w.metrics.get(item.Key, item.Priority)
+				w.dequeued(item)
 				w.locked.Insert(item.Key)
 				w.waiters.Add(-1)
 				delete(w.items, item.Key)
@@ -257,6 +327,56 @@ func (w *priorityqueue[T]) spin() {
 	}
 }
 
+// enqueued computes the fairness tag of an item added to the queue.
+// We use start-time fair queuing: an item's tag is the max of the virtual
+// time and the finish tag of the previous item of its group. Items with the same
+// priority are ordered by tag, which hands them out in a (weighted) round-robin
+// fashion across groups.
+func (w *priorityqueue[T]) enqueued(item *item[T]) {
+	if w.groupFunc == nil {
+		return
+	}
+	item.Group = w.groupFunc(item.Key)
+	g, ok := w.groups[item.Group]
+	if !ok {
+		g = &group{}
+		w.groups[item.Group] = g
+	}
+	weight := 1
+	if w.groupWeight != nil {
+		weight = max(w.groupWeight(item.Group), 1)
+	}
+	item.FairnessTag = max(w.virtualTime, g.finish)
+	g.finish = item.FairnessTag + 1/float64(weight)
+	g.items++
+}
+
+// dequeued updates the fairness state once an item was handed out.
+func (w *priorityqueue[T]) dequeued(item *item[T]) {
+	if w.groupFunc == nil {
+		return
+	}
+	w.virtualTime = max(w.virtualTime, item.FairnessTag)
+	w.removed(item)
+}
+
+// removed updates the fairness state of the item's group once it left the queue.
+func (w *priorityqueue[T]) removed(item *item[T]) {
+	if w.groupFunc == nil {
+		return
+	}
+	g, ok := w.groups[item.Group]
+	if !ok {
+		return
+	}
+	g.items--
+	// Forget empty groups, so that a group does not get any credit
+	// for the time it did not have any item in the queue.
+	if g.items <= 0 {
+		delete(w.groups, item.Group)
+	}
+}
+
 func (w *priorityqueue[T]) Add(item T) {
 	w.AddWithOpts(AddOpts{}, item)
 }
@@ -333,6 +453,57 @@ func (w *priorityqueue[T]) Len() int {
 	return result
 }
 
+func (w *priorityqueue[T]) Snapshot() []ItemInfo[T] {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.lockedLock.RLock()
+	defer w.lockedLock.RUnlock()
+
+	out := make([]ItemInfo[T], 0, len(w.items)+w.locked.Len())
+	w.queue.Ascend(func(item *item[T]) bool {
+		out = append(out, ItemInfo[T]{
+			Key:        item.Key,
+			Priority:   item.Priority,
+			ReadyAt:    item.ReadyAt,
+			Retries:    w.rateLimiter.NumRequeues(item.Key),
+			Waiting:    true,
+			Processing: w.locked.Has(item.Key),
+		})
+		return true
+	})
+	for key := range w.locked {
+		if _, ok := w.items[key]; ok {
+			continue
+		}
+		out = append(out, ItemInfo[T]{
+			Key:        key,
+			Retries:    w.rateLimiter.NumRequeues(key),
+			Processing: true,
+		})
+	}
+	return out
+}
+
+func (w *priorityqueue[T]) Remove(items ...T) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, key := range items {
+		item, ok := w.items[key]
+		if !ok {
+			continue
+		}
+		w.queue.Delete(item)
+		delete(w.items, key)
+		// Only items that are ready were counted in the depth.
+		if item.ReadyAt == nil || w.becameReady.Has(key) {
+			w.metrics.remove(key, item.Priority)
+		}
+		w.becameReady.Delete(key)
+		w.removed(item)
+	}
+}
+
 func (w *priorityqueue[T]) logState() {
 	t := time.Tick(10 * time.Second)
 	for {
@@ -373,6 +544,10 @@ func less[T comparable](a, b *item[T]) bool {
 	if a.Priority != b.Priority {
 		return a.Priority > b.Priority
 	}
+	// The fairness tag is always zero when fair queuing is disabled.
+	if a.FairnessTag != b.FairnessTag {
+		return a.FairnessTag < b.FairnessTag
+	}
 
 	return a.AddedCounter < b.AddedCounter
 }
@@ -382,6 +557,16 @@ type item[T comparable] struct {
 	AddedCounter uint64     `json:"addedCounter"`
 	Priority     int        `json:"priority"`
 	ReadyAt      *time.Time `json:"readyAt,omitempty"`
+	Group        string     `json:"group,omitempty"`
+	FairnessTag  float64    `json:"fairnessTag,omitempty"`
+}
+
+// group is the fair queuing state of a group of items.
+type group struct {
+	// finish is the fairness tag of the group's last added item plus its cost.
+	finish float64
+	// items is the number of items of the group in the queue.
+	items int
 }
 
 func (w *priorityqueue[T]) updateUnfinishedWorkLoop() {
@@ -0,0 +1,121 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityqueue
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newGroupedQueue(t *testing.T, weights map[string]int) PriorityQueue[string] {
+	t.Helper()
+	q := New[string](t.Name(), func(o *Opts[string]) {
+		o.GroupFunc = func(item string) string {
+			group, _, _ := strings.Cut(item, "/")
+			return group
+		}
+		o.GroupWeight = func(group string) int {
+			return weights[group]
+		}
+	})
+	t.Cleanup(q.ShutDown)
+	return q
+}
+
+// get hands out n items, marking them done.
+func get(t *testing.T, q PriorityQueue[string], n int) []string {
+	t.Helper()
+	var out []string
+	for range n {
+		item, _, shutdown := q.GetWithPriority()
+		if shutdown {
+			t.Fatal("queue shut down")
+		}
+		q.Done(item)
+		out = append(out, item)
+	}
+	return out
+}
+
+func groups(items []string) []string {
+	var out []string
+	for _, v := range items {
+		group, _, _ := strings.Cut(v, "/")
+		out = append(out, group)
+	}
+	return out
+}
+
+func TestFairQueuingFloodingGroupDoesNotStarveOthers(t *testing.T) {
+	q := newGroupedQueue(t, nil)
+	for i := range 100 {
+		q.AddWithOpts(AddOpts{}, fmt.Sprintf("flood/%d", i))
+	}
+	for i := range 3 {
+		q.AddWithOpts(AddOpts{}, fmt.Sprintf("quiet/%d", i))
+	}
+	got := groups(get(t, q, 6))
+	want := []string{"flood", "quiet", "flood", "quiet", "flood", "quiet"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected groups %v, got %v", want, got)
+	}
+}
+
+func TestFairQueuingNewGroupIsNotDelayedByBacklog(t *testing.T) {
+	q := newGroupedQueue(t, nil)
+	for i := range 100 {
+		q.AddWithOpts(AddOpts{}, fmt.Sprintf("flood/%d", i))
+	}
+	// the quiet group had no item while the flooding group was served
+	get(t, q, 50)
+	q.AddWithOpts(AddOpts{}, "quiet/0")
+	got := groups(get(t, q, 2))
+	if got[0] != "quiet" && got[1] != "quiet" {
+		t.Fatalf("expected the quiet group to be served within two items, got %v", got)
+	}
+}
+
+func TestFairQueuingWeights(t *testing.T) {
+	q := newGroupedQueue(t, map[string]int{"heavy": 2})
+	for i := range 10 {
+		q.AddWithOpts(AddOpts{}, fmt.Sprintf("heavy/%d", i))
+		q.AddWithOpts(AddOpts{}, fmt.Sprintf("light/%d", i))
+	}
+	counts := map[string]int{}
+	for _, g := range groups(get(t, q, 9)) {
+		counts[g]++
+	}
+	if counts["heavy"] != 6 || counts["light"] != 3 {
+		t.Fatalf("expected 6 heavy and 3 light items, got %v", counts)
+	}
+}
+
+func TestFairQueuingPriorityComesFirst(t *testing.T) {
+	q := newGroupedQueue(t, nil)
+	q.AddWithOpts(AddOpts{}, "quiet/0")
+	for i := range 10 {
+		q.AddWithOpts(AddOpts{Priority: 10}, fmt.Sprintf("flood/%d", i))
+	}
+	got := get(t, q, 11)
+	for i, v := range got[:10] {
+		if !strings.HasPrefix(v, "flood/") {
+			t.Fatalf("expected the high priority items first, got %s at %d: %v", v, i, got)
+		}
+	}
+	if got[10] != "quiet/0" {
+		t.Fatalf("expected the low priority item last, got %v", got)
+	}
+}
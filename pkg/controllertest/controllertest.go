@@ -0,0 +1,291 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controllertest provides a harness to test reconcilers: it starts a controller
+// against an in-memory protodb, with its queue driven by a fake clock so that the
+// backoffs and the RequeueAfter results can be tested deterministically.
+//
+//	env := controllertest.New[pb.Resource](t, key, reconciler)
+//	env.DB.Set(ctx, &pb.Resource{ID: "a"})
+//	env.ExpectReconciled("a")
+//	env.StepTime(time.Minute)
+//	env.ExpectReconciled("a")
+package controllertest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.linka.cloud/protodb"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	controller "go.linka.cloud/protodb-controller"
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+// Reconcile is a reconcile performed by the controller under test.
+type Reconcile[K comparable] struct {
+	Key    K
+	Result reconcile.Result
+	Err    error
+}
+
+// Opts contains the options of an Env.
+type Opts[K comparable] struct {
+	// Name is the name of the controller. Defaults to "test".
+	Name string
+	// DB is the protodb client the controller watches.
+	// Defaults to a new in-memory protodb closed at the end of the test.
+	DB protodb.Client
+	// Options are the options of the controller.
+	// The Reconciler and NewQueue options are set by the Env.
+	Options controller.Options[K]
+	// QueueOpts are additional options of the controller's priority queue.
+	QueueOpts []priorityqueue.Opt[K]
+	// Now is the initial time of the fake clock. Defaults to the current time.
+	Now time.Time
+	// Timeout is the maximum real duration the helpers wait for. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Opt allows to configure an Env.
+type Opt[K comparable] func(*Opts[K])
+
+// WithDB sets the protodb client the controller watches.
+func WithDB[K comparable](db protodb.Client) Opt[K] {
+	return func(o *Opts[K]) {
+		o.DB = db
+	}
+}
+
+// WithOptions sets the options of the controller.
+func WithOptions[K comparable](options controller.Options[K]) Opt[K] {
+	return func(o *Opts[K]) {
+		o.Options = options
+	}
+}
+
+// WithTimeout sets the maximum real duration the helpers wait for.
+func WithTimeout[K comparable](d time.Duration) Opt[K] {
+	return func(o *Opts[K]) {
+		o.Timeout = d
+	}
+}
+
+// Env is a running controller under test.
+type Env[K comparable] struct {
+	// DB is the protodb client watched by the controller.
+	DB protodb.Client
+	// Clock is the fake clock driving the controller's queue.
+	Clock *clocktesting.FakeClock
+	// Controller is the controller under test.
+	Controller controller.TypedController[K]
+
+	t       testing.TB
+	timeout time.Duration
+
+	mu         sync.Mutex
+	reconciles []Reconcile[K]
+	consumed   []bool
+	// updated is closed and replaced when a reconcile is recorded.
+	updated chan struct{}
+}
+
+// New starts a controller reconciling the objects of type T with r, and waits for it to be synced.
+// The controller is stopped at the end of the test.
+func New[T any, PT controller.Message[T], K comparable](t testing.TB, fn controller.Key[PT, K], r controller.Reconciler[K], o ...Opt[K]) *Env[K] {
+	t.Helper()
+	opts := Opts[K]{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Name == "" {
+		opts.Name = "test"
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if opts.DB == nil {
+		db, err := protodb.Open(ctx, protodb.WithInMemory(true))
+		if err != nil {
+			cancel()
+			t.Fatalf("failed to open in-memory protodb: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+		})
+		opts.DB = db
+	}
+	e := &Env[K]{
+		DB:      opts.DB,
+		Clock:   clocktesting.NewFakeClock(opts.Now),
+		t:       t,
+		timeout: opts.Timeout,
+		updated: make(chan struct{}),
+	}
+
+	options := opts.Options
+	options.SkipNameValidation = ptr.To(true)
+	options.Reconciler = reconcile.TypedFunc[K](func(ctx context.Context, req K) (reconcile.Result, error) {
+		res, err := r.Reconcile(ctx, req)
+		e.record(Reconcile[K]{Key: req, Result: res, Err: err})
+		return res, err
+	})
+	if options.RateLimiter == nil {
+		// the default controller rate limiter includes a real time token bucket
+		options.RateLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[K](5*time.Millisecond, 1000*time.Second)
+	}
+	options.NewQueue = func(name string, rl workqueue.TypedRateLimiter[K]) workqueue.TypedRateLimitingInterface[K] {
+		qo := append([]priorityqueue.Opt[K]{func(o *priorityqueue.Opts[K]) {
+			o.RateLimiter = rl
+			o.Clock = e.Clock
+		}}, opts.QueueOpts...)
+		return priorityqueue.New[K](name, qo...)
+	}
+
+	c, err := controller.New[T, PT, K](opts.Name, opts.DB, fn, options)
+	if err != nil {
+		cancel()
+		t.Fatalf("failed to create controller: %v", err)
+	}
+	e.Controller = c
+
+	var startErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		startErr = c.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		if startErr != nil && !errors.Is(startErr, context.Canceled) {
+			t.Errorf("controller failed: %v", startErr)
+		}
+	})
+
+	e.poll("controller to be synced", func() bool {
+		select {
+		case <-done:
+			t.Fatalf("controller failed to start: %v", startErr)
+		default:
+		}
+		return c.Synced()
+	})
+	return e
+}
+
+// StepTime moves the fake clock forward, making ready the requests whose delay expired.
+func (e *Env[K]) StepTime(d time.Duration) {
+	e.Clock.Step(d)
+}
+
+// WaitForIdle waits until no request is being reconciled or ready to be reconciled.
+// Requests waiting for their delay to expire must be made ready with StepTime.
+func (e *Env[K]) WaitForIdle() {
+	e.t.Helper()
+	// the watch events are delivered asynchronously, the controller must stay idle for a few polls
+	idle := 0
+	e.poll("controller to be idle", func() bool {
+		if !e.idle() {
+			idle = 0
+			return false
+		}
+		idle++
+		return idle >= 3
+	})
+}
+
+func (e *Env[K]) idle() bool {
+	items, err := e.Controller.Inspect()
+	if err != nil {
+		return false
+	}
+	now := e.Clock.Now()
+	for _, v := range items {
+		if v.Processing {
+			return false
+		}
+		if v.Waiting && (v.ReadyAt == nil || !v.ReadyAt.After(now)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectReconciled waits for the key to be reconciled and returns the reconcile.
+// Each reconcile is only returned once: calling ExpectReconciled again waits for the next
+// reconcile of the key.
+func (e *Env[K]) ExpectReconciled(key K) Reconcile[K] {
+	e.t.Helper()
+	var r Reconcile[K]
+	timeout := time.After(e.timeout)
+	for {
+		e.mu.Lock()
+		for i, v := range e.reconciles {
+			if !e.consumed[i] && v.Key == key {
+				e.consumed[i] = true
+				r = v
+				e.mu.Unlock()
+				return r
+			}
+		}
+		updated := e.updated
+		e.mu.Unlock()
+		select {
+		case <-updated:
+		case <-timeout:
+			e.t.Fatalf("timed out waiting for %v to be reconciled", key)
+			return r
+		}
+	}
+}
+
+// Reconciles returns all the reconciles performed by the controller.
+func (e *Env[K]) Reconciles() []Reconcile[K] {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.reconciles)
+}
+
+func (e *Env[K]) record(r Reconcile[K]) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reconciles = append(e.reconciles, r)
+	e.consumed = append(e.consumed, false)
+	close(e.updated)
+	e.updated = make(chan struct{})
+}
+
+func (e *Env[K]) poll(what string, cond func() bool) {
+	e.t.Helper()
+	deadline := time.Now().Add(e.timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			e.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	controller "go.linka.cloud/protodb-controller"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+// MigrationOpts contains the options of a Migration.
+type MigrationOpts struct {
+	// Name is the name of the migration controller. Defaults to "migration-<from>-<to>".
+	Name string
+	// Options are the options of the migration controller, e.g. the number of workers
+	// or the rate limiter retrying the failed conversions. The Reconciler is set by the Migration.
+	Options controller.Options[string]
+}
+
+// MigrationOpt allows to configure a Migration.
+type MigrationOpt func(*MigrationOpts)
+
+// WithName sets the name of the migration controller.
+func WithName(name string) MigrationOpt {
+	return func(o *MigrationOpts) {
+		o.Name = name
+	}
+}
+
+// WithOptions sets the options of the migration controller.
+func WithOptions(options controller.Options[string]) MigrationOpt {
+	return func(o *MigrationOpts) {
+		o.Options = options
+	}
+}
+
+// Progress is the progress of a Migration.
+type Progress struct {
+	// Migrated is the number of objects rewritten in the hub version.
+	Migrated int64
+	// Failed is the number of failed conversions or writes, including the retried ones.
+	Failed int64
+	// Remaining is the number of objects waiting to be migrated.
+	Remaining int
+	// Done is true once all the objects listed at start were migrated.
+	// It is reset while the objects written afterwards in the previous version are migrated.
+	Done bool
+}
+
+// Migration is a controller rewriting the objects stored in a previous version in the hub version.
+// Each object is converted, written in the hub version and deleted in its previous version
+// in a single transaction, and the objects modified in the meantime are migrated in their latest state.
+// It keeps migrating the objects written in the previous version until it is stopped,
+// e.g. by the clients not upgraded yet.
+type Migration struct {
+	c controller.TypedController[string]
+
+	migrated atomic.Int64
+	failed   atomic.Int64
+}
+
+// objects holds the objects to migrate by key, until they are migrated.
+// The objects may not have a key field, so that the keys are the hashes of their content.
+type objects[F any, PF typed.Message[F]] struct {
+	mu sync.Mutex
+	m  map[string]PF
+}
+
+// key returns the key of the object, i.e. its type and the hash of its content, and records the object.
+func (o *objects[F, PF]) key(v PF) string {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(v)
+	h := sha256.Sum256(b)
+	k := fmt.Sprintf("%s/%s", v.ProtoReflect().Descriptor().FullName(), hex.EncodeToString(h[:]))
+	o.mu.Lock()
+	o.m[k] = v
+	o.mu.Unlock()
+	return k
+}
+
+func (o *objects[F, PF]) get(key string) (PF, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	v, ok := o.m[key]
+	return v, ok
+}
+
+func (o *objects[F, PF]) delete(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.m, key)
+}
+
+// NewMigration returns a Migration of the objects of type F to the hub type H.
+func NewMigration[F any, PF typed.Message[F], H any, PH typed.Message[H]](db protodb.Client, s *Scheme, o ...MigrationOpt) (*Migration, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if s == nil {
+		return nil, errors.New("scheme is required")
+	}
+	var from PF
+	var to PH
+	fromName, toName := from.ProtoReflect().Descriptor().FullName(), to.ProtoReflect().Descriptor().FullName()
+	if s.path(fromName, toName) == nil {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoConversion, fromName, toName)
+	}
+	opts := MigrationOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Name == "" {
+		opts.Name = fmt.Sprintf("migration-%s-%s", fromName, toName)
+	}
+	m := &Migration{}
+	objs := &objects[F, PF]{m: make(map[string]PF)}
+	opts.Options.Reconciler = reconcile.TypedFunc[string](func(ctx context.Context, key string) (reconcile.Result, error) {
+		old, ok := objs.get(key)
+		if !ok {
+			// already migrated, or enqueued before a restart: the objects left are listed again
+			return reconcile.Result{}, nil
+		}
+		ok, err := migrate(ctx, db, s, toName, old)
+		if err != nil {
+			m.failed.Add(1)
+			return reconcile.Result{}, err
+		}
+		objs.delete(key)
+		if ok {
+			m.migrated.Add(1)
+		}
+		return reconcile.Result{}, nil
+	})
+	c, err := controller.New[F, PF, string](opts.Name, db, controller.KeyFunc[PF, string](objs.key), opts.Options)
+	if err != nil {
+		return nil, err
+	}
+	m.c = c
+	return m, nil
+}
+
+// migrate migrates the object if it is still stored in this state, and reports whether it did.
+func migrate(ctx context.Context, db protodb.Client, s *Scheme, to protoreflect.FullName, old proto.Message) (bool, error) {
+	tx, err := db.Tx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Close()
+	rs, _, err := tx.Get(ctx, old)
+	if err != nil {
+		return false, err
+	}
+	// already migrated or deleted, or modified and enqueued again in its new state
+	if len(rs) == 0 || !proto.Equal(rs[0], old) {
+		return false, nil
+	}
+	v, err := s.Convert(ctx, old, to)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Set(ctx, v); err != nil {
+		return false, err
+	}
+	if err := tx.Delete(ctx, old); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Start starts the migration. It blocks until the context is done.
+func (m *Migration) Start(ctx context.Context) error {
+	return m.c.Start(ctx)
+}
+
+// Progress returns the progress of the migration.
+func (m *Migration) Progress() Progress {
+	s := m.c.Status()
+	remaining := s.QueueDepth + s.ActiveWorkers
+	return Progress{
+		Migrated:  m.migrated.Load(),
+		Failed:    m.failed.Load(),
+		Remaining: remaining,
+		Done:      s.Synced && remaining == 0,
+	}
+}
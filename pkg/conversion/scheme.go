@@ -0,0 +1,189 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conversion provides the conversion of the stored messages between their versions,
+// i.e. distinct message types, e.g. resources.v1.Resource and resources.v2.Resource.
+//
+// The converters between the versions are registered in a Scheme. A version is converted to another one
+// by chaining the converters, so that registering the conversions between consecutive versions is enough.
+// The latest version is the hub, the version the reconcilers work with:
+//
+//   - the Store returned by NewStore reads and watches all the versions of the objects as the hub version,
+//     and writes them in the hub version, removing their previous versions.
+//   - the Migration controller rewrites the stored records of a previous version in the hub version
+//     in the background.
+//
+// Example:
+//
+//	s := conversion.NewScheme()
+//	conversion.Register(s, func(ctx context.Context, m *v1.Resource) (*v2.Resource, error) {
+//		return &v2.Resource{ID: m.ID, Spec: &v2.Spec{Replicas: m.Replicas}}, nil
+//	})
+//	conversion.Register(s, func(ctx context.Context, m *v2.Resource) (*v1.Resource, error) {
+//		return &v1.Resource{ID: m.ID, Replicas: m.GetSpec().GetReplicas()}, nil
+//	})
+//	m, _ := conversion.NewMigration[v1.Resource, *v1.Resource, v2.Resource](db, s)
+//	go m.Start(ctx)
+//	r := &reconciler{db: conversion.NewStore[v2.Resource](db, s)}
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrNoConversion is returned when no chain of converters converts a type to another.
+var ErrNoConversion = errors.New("no conversion")
+
+// Converter converts a message to another version.
+type Converter func(ctx context.Context, m proto.Message) (proto.Message, error)
+
+type conversion struct {
+	to protoreflect.MessageType
+	fn Converter
+}
+
+// Scheme holds the converters between the versions of the messages. It is safe for concurrent use.
+type Scheme struct {
+	mu          sync.RWMutex
+	conversions map[protoreflect.FullName][]conversion
+	types       map[protoreflect.FullName]protoreflect.MessageType
+}
+
+// NewScheme returns an empty Scheme.
+func NewScheme() *Scheme {
+	return &Scheme{
+		conversions: make(map[protoreflect.FullName][]conversion),
+		types:       make(map[protoreflect.FullName]protoreflect.MessageType),
+	}
+}
+
+// AddConverter registers the converter of the messages of type from to the type to.
+// It replaces the converter previously registered for the same types.
+func (s *Scheme) AddConverter(from, to protoreflect.MessageType, fn Converter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := from.Descriptor().FullName()
+	s.types[name] = from
+	s.types[to.Descriptor().FullName()] = to
+	s.conversions[name] = slices.DeleteFunc(s.conversions[name], func(c conversion) bool {
+		return c.to.Descriptor().FullName() == to.Descriptor().FullName()
+	})
+	s.conversions[name] = append(s.conversions[name], conversion{to: to, fn: fn})
+}
+
+// Register registers the converter of the messages of type F to the type T.
+func Register[F any, PF typed.Message[F], T any, PT typed.Message[T]](s *Scheme, fn func(ctx context.Context, m PF) (PT, error)) {
+	var from PF
+	var to PT
+	s.AddConverter(from.ProtoReflect().Type(), to.ProtoReflect().Type(), func(ctx context.Context, m proto.Message) (proto.Message, error) {
+		v, err := cast[F, PF](m)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, v)
+	})
+}
+
+// Convert converts m to the type to, chaining the converters if needed.
+// It returns m if it already is of the type to, and ErrNoConversion if it cannot be converted.
+func (s *Scheme) Convert(ctx context.Context, m proto.Message, to protoreflect.FullName) (proto.Message, error) {
+	from := m.ProtoReflect().Descriptor().FullName()
+	path := s.path(from, to)
+	if path == nil {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoConversion, from, to)
+	}
+	for _, c := range path {
+		out, err := c.fn(ctx, m)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s to %s: %w", m.ProtoReflect().Descriptor().FullName(), c.to.Descriptor().FullName(), err)
+		}
+		m = out
+	}
+	return m, nil
+}
+
+// Versions returns the types which can be converted to the type to, excluding it, sorted by name.
+func (s *Scheme) Versions(to protoreflect.FullName) []protoreflect.MessageType {
+	s.mu.RLock()
+	types := maps.Clone(s.types)
+	s.mu.RUnlock()
+	var out []protoreflect.MessageType
+	for _, name := range slices.Sorted(maps.Keys(types)) {
+		if name != to && s.path(name, to) != nil {
+			out = append(out, types[name])
+		}
+	}
+	return out
+}
+
+// path returns the shortest chain of conversions from a type to another, an empty one if they are the same,
+// or nil if there is none.
+func (s *Scheme) path(from, to protoreflect.FullName) []conversion {
+	if from == to {
+		return []conversion{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type step struct {
+		name protoreflect.FullName
+		path []conversion
+	}
+	seen := map[protoreflect.FullName]bool{from: true}
+	queue := []step{{name: from}}
+	for len(queue) != 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, c := range s.conversions[cur.name] {
+			name := c.to.Descriptor().FullName()
+			if seen[name] {
+				continue
+			}
+			path := append(slices.Clone(cur.path), c)
+			if name == to {
+				return path
+			}
+			seen[name] = true
+			queue = append(queue, step{name: name, path: path})
+		}
+	}
+	return nil
+}
+
+// cast returns m as a PT, converting it if it is another Go type of the same message type, e.g. a dynamic message.
+func cast[T any, PT typed.Message[T]](m proto.Message) (PT, error) {
+	if v, ok := m.(PT); ok {
+		return v, nil
+	}
+	var v PT = new(T)
+	if m.ProtoReflect().Descriptor().FullName() != v.ProtoReflect().Descriptor().FullName() {
+		return nil, fmt.Errorf("unexpected message type %s, expected %s", m.ProtoReflect().Descriptor().FullName(), v.ProtoReflect().Descriptor().FullName())
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,324 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"go.linka.cloud/protodb-controller/pkg/store"
+)
+
+// ErrPaging is returned when paging the objects of several versions.
+var ErrPaging = errors.New("paging is not supported across versions")
+
+// NewStore returns a Store of the objects of the hub type T, which also reads and watches the objects stored
+// in the versions registered in the scheme as converted to T, so that the reconcilers only see the hub version.
+//
+// The objects are always written in the hub version, their previous versions being deleted in the same transaction.
+// The queries are converted to each version, so that the converters from the hub to the previous versions
+// must be registered too, and the Get options, e.g. the filters, must be valid for all the versions.
+// The results cannot be paged across the versions: Get fails with ErrPaging if paging is requested
+// while objects matching the query are stored in a previous version, i.e. until they are migrated.
+func NewStore[T any, PT typed.Message[T]](db protodb.Client, s *Scheme) store.Store[T, PT] {
+	var z PT
+	return &hubStore[T, PT]{db: db, s: s, hub: z.ProtoReflect().Descriptor().FullName()}
+}
+
+type hubStore[T any, PT typed.Message[T]] struct {
+	db  protodb.Client
+	s   *Scheme
+	hub protoreflect.FullName
+}
+
+func (h *hubStore[T, PT]) Get(ctx context.Context, m PT, opts ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error) {
+	return h.get(ctx, h.db, m, opts...)
+}
+
+func (h *hubStore[T, PT]) Set(ctx context.Context, m PT, opts ...protodb.SetOption) (PT, error) {
+	tx, err := h.db.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	v, err := h.set(ctx, tx, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (h *hubStore[T, PT]) Delete(ctx context.Context, m PT) error {
+	tx, err := h.db.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if err := h.delete(ctx, tx, m); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (h *hubStore[T, PT]) Tx(ctx context.Context, opts ...protodb.TxOption) (typed.Tx[T, PT], error) {
+	tx, err := h.db.Tx(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &hubTx[T, PT]{h: h, tx: tx}, nil
+}
+
+// Watch merges the events of all the versions, converted to the hub version.
+// The deletion of a previous version is dropped when the object exists in the hub version,
+// as it is the migration of the object, notified by the creation of its hub version.
+func (h *hubStore[T, PT]) Watch(ctx context.Context, m PT, opts ...protodb.GetOption) (<-chan typed.Event[T, PT], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	queries, err := h.queries(ctx, m)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	chs := make([]<-chan protodb.Event, 0, len(queries))
+	for _, q := range queries {
+		ch, err := h.db.Watch(ctx, q, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		chs = append(chs, ch)
+	}
+	out := make(chan typed.Event[T, PT])
+	var wg sync.WaitGroup
+	for _, ch := range chs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range ch {
+				if e == nil {
+					continue
+				}
+				v, ok := h.event(ctx, e)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out, nil
+}
+
+// event converts e to the hub version. It returns false if the event must be dropped.
+func (h *hubStore[T, PT]) event(ctx context.Context, e protodb.Event) (typed.Event[T, PT], bool) {
+	if e.Err() != nil {
+		return event[T, PT]{err: e.Err()}, true
+	}
+	v := event[T, PT]{typ: e.Type()}
+	var err error
+	if e.Old() != nil {
+		if v.old, err = h.convert(ctx, e.Old()); err != nil {
+			return event[T, PT]{err: err}, true
+		}
+	}
+	if e.New() != nil {
+		if v.new, err = h.convert(ctx, e.New()); err != nil {
+			return event[T, PT]{err: err}, true
+		}
+	}
+	if e.Type() != protodb.EventTypeLeave || e.Old() == nil || e.Old().ProtoReflect().Descriptor().FullName() == h.hub {
+		return v, true
+	}
+	rs, _, err := h.db.Get(ctx, v.old)
+	if err != nil {
+		return event[T, PT]{err: err}, true
+	}
+	return v, len(rs) == 0
+}
+
+func (h *hubStore[T, PT]) get(ctx context.Context, r protodb.Reader, m PT, opts ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error) {
+	queries, err := h.queries(ctx, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	var o protodb.GetOpts
+	for _, f := range opts {
+		f(&o)
+	}
+	if o.Paging != nil && len(queries) > 1 {
+		// the hub version can be paged once no previous version is stored
+		var fopts []protodb.GetOption
+		if o.Filter != nil {
+			fopts = append(fopts, protodb.WithFilter(o.Filter))
+		}
+		for _, q := range queries[1:] {
+			rs, _, err := r.Get(ctx, q, fopts...)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(rs) != 0 {
+				return nil, nil, fmt.Errorf("%w: %s objects are not migrated yet", ErrPaging, q.ProtoReflect().Descriptor().FullName())
+			}
+		}
+		queries = queries[:1]
+	}
+	var out []PT
+	var info *protodb.PagingInfo
+	for i, q := range queries {
+		rs, pi, err := r.Get(ctx, q, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			info = pi
+		}
+		for _, v := range rs {
+			c, err := h.convert(ctx, v)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, c)
+		}
+	}
+	return out, info, nil
+}
+
+func (h *hubStore[T, PT]) set(ctx context.Context, tx protodb.Tx, m PT, opts ...protodb.SetOption) (PT, error) {
+	if err := h.deletePrevious(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	v, err := tx.Set(ctx, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cast[T, PT](v)
+}
+
+func (h *hubStore[T, PT]) delete(ctx context.Context, tx protodb.Tx, m PT) error {
+	if err := h.deletePrevious(ctx, tx, m); err != nil {
+		return err
+	}
+	return tx.Delete(ctx, m)
+}
+
+// deletePrevious deletes the objects stored in the previous versions matching m.
+func (h *hubStore[T, PT]) deletePrevious(ctx context.Context, tx protodb.Tx, m PT) error {
+	queries, err := h.queries(ctx, m)
+	if err != nil {
+		return err
+	}
+	for _, q := range queries[1:] {
+		rs, _, err := tx.Get(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, v := range rs {
+			if err := tx.Delete(ctx, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// queries returns m followed by its conversions to the previous versions.
+func (h *hubStore[T, PT]) queries(ctx context.Context, m PT) ([]proto.Message, error) {
+	if m == nil {
+		m = new(T)
+	}
+	out := []proto.Message{m}
+	for _, t := range h.s.Versions(h.hub) {
+		q, err := h.s.Convert(ctx, m, t.Descriptor().FullName())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, nil
+}
+
+func (h *hubStore[T, PT]) convert(ctx context.Context, m proto.Message) (PT, error) {
+	v, err := h.s.Convert(ctx, m, h.hub)
+	if err != nil {
+		return nil, err
+	}
+	return cast[T, PT](v)
+}
+
+type hubTx[T any, PT typed.Message[T]] struct {
+	h  *hubStore[T, PT]
+	tx protodb.Tx
+}
+
+func (t *hubTx[T, PT]) Get(ctx context.Context, m PT, opts ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error) {
+	return t.h.get(ctx, t.tx, m, opts...)
+}
+
+func (t *hubTx[T, PT]) Set(ctx context.Context, m PT, opts ...protodb.SetOption) (PT, error) {
+	return t.h.set(ctx, t.tx, m, opts...)
+}
+
+func (t *hubTx[T, PT]) Delete(ctx context.Context, m PT) error {
+	return t.h.delete(ctx, t.tx, m)
+}
+
+func (t *hubTx[T, PT]) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *hubTx[T, PT]) Close() {
+	t.tx.Close()
+}
+
+type event[T any, PT typed.Message[T]] struct {
+	typ protodb.EventType
+	old PT
+	new PT
+	err error
+}
+
+func (e event[T, PT]) Type() protodb.EventType {
+	return e.typ
+}
+
+func (e event[T, PT]) Old() PT {
+	return e.old
+}
+
+func (e event[T, PT]) New() PT {
+	return e.new
+}
+
+func (e event[T, PT]) Err() error {
+	return e.err
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deadletter provides the storage of the requests a controller gave up reconciling,
+// either because they failed with a terminal error or more times than the controller's MaxRetries.
+//
+// Dead-lettered requests can be listed and inspected with the Store, and re-driven,
+// i.e. removed from the store and reconciled again, with the controller's Redrive method.
+package deadletter
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"go.linka.cloud/protofilters/filters"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.linka.cloud/protodb-controller/pkg/codec"
+	"go.linka.cloud/protodb-controller/pkg/pb"
+)
+
+// Entry is a dead-lettered request.
+type Entry[T comparable] struct {
+	Request T
+	// Error is the last error returned by the reconciler.
+	Error string
+	// Attempts is the number of times the request was reconciled.
+	Attempts int
+	// Terminal is true when the request was dead-lettered because of a terminal error.
+	Terminal     bool
+	FirstFailure time.Time
+	LastFailure  time.Time
+}
+
+// Store stores the dead-lettered requests of a controller.
+type Store[T comparable] interface {
+	// Put stores the entry, replacing any entry for the same request.
+	Put(ctx context.Context, e Entry[T]) error
+	// Get returns the entry of the request, the boolean is false if there is none.
+	Get(ctx context.Context, req T) (Entry[T], bool, error)
+	// List returns all the entries.
+	List(ctx context.Context) ([]Entry[T], error)
+	// Delete removes the entry of the request.
+	Delete(ctx context.Context, req T) error
+}
+
+// Opts contains the options for a protodb Store.
+type Opts[T comparable] struct {
+	// Codec encodes the requests to store them. Defaults to codec.JSON.
+	Codec codec.Codec[T]
+}
+
+// Opt allows to configure a protodb Store.
+type Opt[T comparable] func(*Opts[T])
+
+// New returns a Store keeping the dead-lettered requests of the named controller in db.
+func New[T comparable](ctx context.Context, db protodb.Client, controller string, o ...Opt[T]) (Store[T], error) {
+	opts := &Opts[T]{}
+	for _, f := range o {
+		f(opts)
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.JSON[T]{}
+	}
+	if err := db.Register(ctx, pb.File_pb_deadletter_proto); err != nil {
+		return nil, err
+	}
+	return &store[T]{
+		db:         typed.NewStore[pb.DeadLetter](db),
+		controller: controller,
+		codec:      opts.Codec,
+	}, nil
+}
+
+type store[T comparable] struct {
+	db         typed.Store[pb.DeadLetter, *pb.DeadLetter]
+	controller string
+	codec      codec.Codec[T]
+}
+
+func (s *store[T]) Put(ctx context.Context, e Entry[T]) error {
+	id, k, err := s.id(e.Request)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Set(ctx, &pb.DeadLetter{
+		ID:           id,
+		Controller:   s.controller,
+		Key:          k,
+		Error:        e.Error,
+		Attempts:     uint32(e.Attempts),
+		Terminal:     e.Terminal,
+		FirstFailure: timestamppb.New(e.FirstFailure),
+		LastFailure:  timestamppb.New(e.LastFailure),
+	})
+	return err
+}
+
+func (s *store[T]) Get(ctx context.Context, req T) (Entry[T], bool, error) {
+	id, _, err := s.id(req)
+	if err != nil {
+		return Entry[T]{}, false, err
+	}
+	rs, _, err := s.db.Get(ctx, &pb.DeadLetter{ID: id})
+	if err != nil || len(rs) == 0 {
+		return Entry[T]{}, false, err
+	}
+	e, err := s.entry(rs[0])
+	return e, err == nil, err
+}
+
+func (s *store[T]) List(ctx context.Context) ([]Entry[T], error) {
+	rs, _, err := s.db.Get(ctx, &pb.DeadLetter{}, protodb.WithFilter(filters.Where("controller").StringEquals(s.controller)))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Entry[T], 0, len(rs))
+	for _, v := range rs {
+		e, err := s.entry(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (s *store[T]) Delete(ctx context.Context, req T) error {
+	id, _, err := s.id(req)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(ctx, &pb.DeadLetter{ID: id})
+}
+
+func (s *store[T]) entry(m *pb.DeadLetter) (Entry[T], error) {
+	req, err := s.codec.Unmarshal(m.Key)
+	if err != nil {
+		return Entry[T]{}, fmt.Errorf("decode %s: %w", m.ID, err)
+	}
+	return Entry[T]{
+		Request:      req,
+		Error:        m.Error,
+		Attempts:     int(m.Attempts),
+		Terminal:     m.Terminal,
+		FirstFailure: m.FirstFailure.AsTime(),
+		LastFailure:  m.LastFailure.AsTime(),
+	}, nil
+}
+
+func (s *store[T]) id(req T) (string, []byte, error) {
+	b, err := s.codec.Marshal(req)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s/%s", s.controller, base64.RawURLEncoding.EncodeToString(b)), b, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debug provides an HTTP handler to inspect the queue of a controller,
+// and to requeue or forget some of its keys.
+//
+// The handler serves the following endpoints, relative to where it is mounted:
+//
+//	GET  /         lists the queue items as JSON
+//	POST /requeue  enqueues the keys, the body being {"keys": [...]}
+//	POST /forget   removes the waiting keys and resets their retries, the body being {"keys": [...]}
+//
+// The keys are encoded as JSON.
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	logf "go.linka.cloud/protodb-controller/pkg/internal/log"
+	"go.linka.cloud/protodb-controller/pkg/server"
+)
+
+var log = logf.RuntimeLog.WithName("debug")
+
+// Controller is implemented by the controllers.
+type Controller[K comparable] interface {
+	Inspect() ([]priorityqueue.ItemInfo[K], error)
+	Enqueue(keys ...K) error
+	Forget(keys ...K) error
+}
+
+// Path returns the path the named controller's handler is mounted on by Handle.
+func Path(controller string) string {
+	return "/debug/controllers/" + controller + "/"
+}
+
+// Handle registers the handler of the named controller on the server, at Path(controller).
+func Handle[K comparable](s *server.Server, controller string, c Controller[K]) error {
+	p := Path(controller)
+	return s.Handle(p, http.StripPrefix(p[:len(p)-1], Handler(c)))
+}
+
+// Handler returns the debug handler of the controller.
+func Handler[K comparable](c Controller[K]) http.Handler {
+	return &handler[K]{c: c}
+}
+
+type handler[K comparable] struct {
+	c Controller[K]
+}
+
+type keysRequest[K comparable] struct {
+	Keys []K `json:"keys"`
+}
+
+type itemsResponse[K comparable] struct {
+	Items []priorityqueue.ItemInfo[K] `json:"items"`
+}
+
+func (h *handler[K]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path
+	if p == "" || p[0] != '/' {
+		p = "/" + p
+	}
+	switch p = path.Clean(p); p {
+	case "/":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		items, err := h.c.Inspect()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if items == nil {
+			items = []priorityqueue.ItemInfo[K]{}
+		}
+		writeJSON(w, itemsResponse[K]{Items: items})
+	case "/requeue", "/forget":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req keysRequest[K]
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		fn := h.c.Enqueue
+		if p == "/forget" {
+			fn = h.c.Forget
+		}
+		if err := fn(req.Keys...); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(err, "Failed to write response")
+	}
+}
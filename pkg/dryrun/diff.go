@@ -0,0 +1,123 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Change is a field changed by a write.
+type Change struct {
+	// Path is the dot separated path of the field.
+	Path string `json:"path"`
+	// Old is the JSON encoded previous value of the field, it is not set if the field was not set.
+	Old json.RawMessage `json:"old,omitempty"`
+	// New is the JSON encoded new value of the field, it is not set if the field was cleared.
+	New json.RawMessage `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, value(c.Old), value(c.New))
+}
+
+func value(b json.RawMessage) string {
+	if b == nil {
+		return "<unset>"
+	}
+	return string(b)
+}
+
+// Diff returns the fields which differ between old and new. A nil old message is an object
+// created, and a nil new message an object deleted.
+// The singular message fields are compared recursively, the other fields as a whole.
+func Diff(old, new proto.Message) []Change {
+	var o, n protoreflect.Message
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		n = new.ProtoReflect()
+		o = n.Type().Zero()
+	case new == nil:
+		o = old.ProtoReflect()
+		n = o.Type().Zero()
+	default:
+		o, n = old.ProtoReflect(), new.ProtoReflect()
+		if o.Descriptor().FullName() != n.Descriptor().FullName() {
+			return []Change{{Path: "", Old: marshal(o.Interface()), New: marshal(n.Interface())}}
+		}
+	}
+	return diff("", o, n)
+}
+
+func diff(prefix string, o, n protoreflect.Message) []Change {
+	var changes []Change
+	fields := o.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		if !o.Has(fd) && !n.Has(fd) {
+			continue
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && o.Has(fd) && n.Has(fd) {
+			changes = append(changes, diff(path+".", o.Get(fd).Message(), n.Get(fd).Message())...)
+			continue
+		}
+		ov, nv := field(o, fd), field(n, fd)
+		if proto.Equal(ov, nv) {
+			continue
+		}
+		c := Change{Path: path}
+		if o.Has(fd) {
+			c.Old = fieldJSON(ov, fd)
+		}
+		if n.Has(fd) {
+			c.New = fieldJSON(nv, fd)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// field returns a message containing only the field fd of m, allowing to compare and encode it.
+func field(m protoreflect.Message, fd protoreflect.FieldDescriptor) proto.Message {
+	out := m.Type().New()
+	if m.Has(fd) {
+		out.Set(fd, m.Get(fd))
+	}
+	return out.Interface()
+}
+
+// fieldJSON returns the JSON encoded value of the field fd of m.
+func fieldJSON(m proto.Message, fd protoreflect.FieldDescriptor) json.RawMessage {
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(marshal(m), &v); err != nil {
+		return nil
+	}
+	return v[string(fd.Name())]
+}
+
+func marshal(m proto.Message) json.RawMessage {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return b
+}
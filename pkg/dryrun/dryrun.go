@@ -0,0 +1,248 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dryrun provides a protodb client which logs the writes as diffs instead of applying them,
+// allowing to run a new controller against production data without modifying it.
+//
+// The reconciler must use the dry-run client for its writes, and the controller must be given the
+// client with the DryRun option so that the results of the reconciles are summarised in the report
+// logged when the controller stops:
+//
+//	dr := dryrun.New(db)
+//	c, _ := controller.New(name, db, key, controller.Options[string]{
+//		Reconciler: &reconciler{db: typed.NewStore[pb.Resource](dr)},
+//		DryRun:     dr,
+//	})
+package dryrun
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.linka.cloud/protodb"
+	"google.golang.org/protobuf/proto"
+
+	"go.linka.cloud/protodb-controller/pkg/log"
+)
+
+// Op is the operation of a write.
+type Op string
+
+const (
+	OpSet    Op = "set"
+	OpDelete Op = "delete"
+)
+
+// DefaultMaxWrites is the default number of writes kept in the report.
+const DefaultMaxWrites = 1000
+
+// Opts contains the options of a dry-run Client.
+type Opts struct {
+	// MaxWrites is the number of writes kept in the report, the following ones are only counted,
+	// so that a long dry run does not grow the memory without limit. Defaults to DefaultMaxWrites.
+	// A negative value keeps all the writes.
+	MaxWrites int
+	// Objects keeps a copy of the objects written in the report's writes.
+	Objects bool
+}
+
+// Opt allows to configure a dry-run Client.
+type Opt func(*Opts)
+
+// WithMaxWrites sets the number of writes kept in the report.
+func WithMaxWrites(n int) Opt {
+	return func(o *Opts) {
+		o.MaxWrites = n
+	}
+}
+
+// WithObjects keeps a copy of the objects written in the report's writes.
+func WithObjects() Opt {
+	return func(o *Opts) {
+		o.Objects = true
+	}
+}
+
+// Write is a write intercepted by the dry-run client.
+type Write struct {
+	Time time.Time
+	Op   Op
+	// Type is the full name of the message type.
+	Type string
+	// Tx is true if the write was made in a transaction, in which case it is only
+	// reported once the transaction is committed.
+	Tx bool
+	// Object is a copy of the object set, or of the object passed to Delete.
+	// It is only kept when the client was created with WithObjects.
+	Object proto.Message
+	// Changes are the fields changed by the write, compared to the stored object.
+	Changes []Change
+}
+
+// Client is a protodb.Client applying the reads and logging the writes instead of applying them.
+// The Register calls and the other methods of the client are passed through.
+type Client struct {
+	protodb.Client
+	opts Opts
+
+	mu     sync.Mutex
+	report Report
+}
+
+// New returns a dry-run client reading from db.
+func New(db protodb.Client, o ...Opt) *Client {
+	opts := Opts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.MaxWrites == 0 {
+		opts.MaxWrites = DefaultMaxWrites
+	}
+	return &Client{Client: db, opts: opts, report: Report{Types: make(map[string]Counts), Reconciles: make(map[string]int)}}
+}
+
+// Set logs the changes of the object, and returns it as if it was stored.
+// The set options are ignored.
+func (c *Client) Set(ctx context.Context, m proto.Message, _ ...protodb.SetOption) (proto.Message, error) {
+	c.write(ctx, c.set(ctx, c.Client, m, false))
+	return proto.Clone(m), nil
+}
+
+// Delete logs the deletion of the object.
+func (c *Client) Delete(ctx context.Context, m proto.Message) error {
+	c.write(ctx, c.delete(ctx, c.Client, m, false))
+	return nil
+}
+
+// Tx returns a transaction reading from a transaction of the underlying client, whose writes
+// are logged when it is committed.
+func (c *Client) Tx(ctx context.Context, opts ...protodb.TxOption) (protodb.Tx, error) {
+	tx, err := c.Client.Tx(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, c: c}, nil
+}
+
+// Report returns a copy of the report of the writes and of the reconciles.
+func (c *Client) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.report.clone()
+}
+
+func (c *Client) set(ctx context.Context, r protodb.Reader, m proto.Message, tx bool) Write {
+	return Write{
+		Time:    time.Now(),
+		Op:      OpSet,
+		Type:    string(m.ProtoReflect().Descriptor().FullName()),
+		Tx:      tx,
+		Object:  c.object(m),
+		Changes: Diff(current(ctx, r, m), m),
+	}
+}
+
+func (c *Client) delete(ctx context.Context, r protodb.Reader, m proto.Message, tx bool) Write {
+	w := Write{
+		Time:   time.Now(),
+		Op:     OpDelete,
+		Type:   string(m.ProtoReflect().Descriptor().FullName()),
+		Tx:     tx,
+		Object: c.object(m),
+	}
+	if old := current(ctx, r, m); old != nil {
+		w.Changes = Diff(old, nil)
+	}
+	return w
+}
+
+func (c *Client) object(m proto.Message) proto.Message {
+	if !c.opts.Objects {
+		return nil
+	}
+	return proto.Clone(m)
+}
+
+func (c *Client) write(ctx context.Context, w ...Write) {
+	c.mu.Lock()
+	for _, v := range w {
+		n := c.report.Types[v.Type]
+		switch v.Op {
+		case OpSet:
+			n.Sets++
+		case OpDelete:
+			n.Deletes++
+		}
+		c.report.Types[v.Type] = n
+		if c.opts.MaxWrites < 0 || len(c.report.Writes) < c.opts.MaxWrites {
+			c.report.Writes = append(c.report.Writes, v)
+		} else {
+			c.report.DroppedWrites++
+		}
+	}
+	c.mu.Unlock()
+	for _, v := range w {
+		log.FromContext(ctx).Info("Dry-run: write not applied", "op", v.Op, "type", v.Type, "tx", v.Tx, "changes", v.Changes)
+	}
+}
+
+// current returns the stored version of the object, or nil if it does not exist or cannot be read.
+func current(ctx context.Context, r protodb.Reader, m proto.Message) proto.Message {
+	rs, _, err := r.Get(ctx, m)
+	if err != nil || len(rs) != 1 {
+		return nil
+	}
+	return rs[0]
+}
+
+// txn reads from the underlying transaction and buffers the writes until the commit.
+// Its reads do not see its own writes.
+type txn struct {
+	protodb.Tx
+	c *Client
+
+	mu     sync.Mutex
+	writes []Write
+}
+
+func (t *txn) Set(ctx context.Context, m proto.Message, _ ...protodb.SetOption) (proto.Message, error) {
+	w := t.c.set(ctx, t.Tx, m, true)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writes = append(t.writes, w)
+	return proto.Clone(m), nil
+}
+
+func (t *txn) Delete(ctx context.Context, m proto.Message) error {
+	w := t.c.delete(ctx, t.Tx, m, true)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writes = append(t.writes, w)
+	return nil
+}
+
+// Commit logs the writes of the transaction and closes the underlying transaction without committing it.
+func (t *txn) Commit(ctx context.Context) error {
+	t.mu.Lock()
+	writes := t.writes
+	t.writes = nil
+	t.mu.Unlock()
+	t.Tx.Close()
+	t.c.mu.Lock()
+	t.c.report.Commits++
+	t.c.mu.Unlock()
+	t.c.write(ctx, writes...)
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/go-logr/logr"
+
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+// Counts are the numbers of writes of a message type.
+type Counts struct {
+	Sets    int
+	Deletes int
+}
+
+// Report summarises the writes intercepted by a dry-run client and the reconciles which made them.
+type Report struct {
+	// Writes are the first writes intercepted, in order, up to the client's MaxWrites.
+	Writes []Write
+	// DroppedWrites is the number of writes intercepted but not kept in Writes.
+	DroppedWrites int
+	// Types are the numbers of writes by message type, including the dropped writes.
+	Types map[string]Counts
+	// Commits is the number of transactions committed.
+	Commits int
+	// Reconciles is the number of reconciles by result, i.e. success, error, requeue or requeue_after.
+	Reconciles map[string]int
+}
+
+// Sets returns the number of objects set.
+func (r Report) Sets() int {
+	n := 0
+	for _, v := range r.Types {
+		n += v.Sets
+	}
+	return n
+}
+
+// Deletes returns the number of objects deleted.
+func (r Report) Deletes() int {
+	n := 0
+	for _, v := range r.Types {
+		n += v.Deletes
+	}
+	return n
+}
+
+// Log logs the summary of the report.
+func (r Report) Log(log logr.Logger) {
+	kv := []any{"sets", r.Sets(), "deletes", r.Deletes(), "commits", r.Commits, "dropped_writes", r.DroppedWrites}
+	for _, k := range slices.Sorted(maps.Keys(r.Reconciles)) {
+		kv = append(kv, "reconciles_"+k, r.Reconciles[k])
+	}
+	log.Info("Dry-run report", kv...)
+	for _, k := range slices.Sorted(maps.Keys(r.Types)) {
+		log.Info("Dry-run report", "type", k, "sets", r.Types[k].Sets, "deletes", r.Types[k].Deletes)
+	}
+}
+
+func (r Report) clone() Report {
+	r.Writes = slices.Clone(r.Writes)
+	r.Types = maps.Clone(r.Types)
+	r.Reconciles = maps.Clone(r.Reconciles)
+	return r
+}
+
+// Reconciler returns a reconciler recording the results of r in the report of c.
+func Reconciler[request comparable](c *Client, r reconcile.TypedReconciler[request]) reconcile.TypedReconciler[request] {
+	return reconcile.TypedFunc[request](func(ctx context.Context, req request) (reconcile.Result, error) {
+		res, err := r.Reconcile(ctx, req)
+		result := "success"
+		switch {
+		case err != nil:
+			result = "error"
+		case res.RequeueAfter > 0:
+			result = "requeue_after"
+		case res.Requeue:
+			result = "requeue"
+		}
+		c.mu.Lock()
+		c.report.Reconciles[result]++
+		c.mu.Unlock()
+		return res, err
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -32,9 +33,13 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
-	ctrlmetrics "go.linka.cloud/protodb-controller/pkg/internal/controller/metrics"
+	"go.linka.cloud/protodb-controller/pkg/deadletter"
+	"go.linka.cloud/protodb-controller/pkg/dryrun"
+	"go.linka.cloud/protodb-controller/pkg/lease"
 	logf "go.linka.cloud/protodb-controller/pkg/log"
+	"go.linka.cloud/protodb-controller/pkg/metrics"
 	"go.linka.cloud/protodb-controller/pkg/reconcile"
+	"go.linka.cloud/protodb-controller/pkg/sharding"
 	"go.linka.cloud/protodb-controller/pkg/source"
 )
 
@@ -44,8 +49,12 @@ type Controller[request comparable] struct {
 	Name string
 
 	// MaxConcurrentReconciles is the maximum number of concurrent Reconciles which can be run. Defaults to 1.
+	// Once started, it must only be updated with SetWorkers.
 	MaxConcurrentReconciles int
 
+	// Autoscale, if set, enables the adaptive scaling of the workers.
+	Autoscale *Autoscale
+
 	// Reconciler is a function that can be called at any time with the Name / Namespace of an object and
 	// ensures that the state of the system matches the state specified in the object.
 	// Defaults to the DefaultReconcileFunc.
@@ -69,6 +78,10 @@ type Controller[request comparable] struct {
 	// Started is true if the Controller has been Started
 	Started bool
 
+	// synced is true once the sources are synced and the workers started.
+	// Unlike Started, it can be read without holding mu.
+	synced atomic.Bool
+
 	// ctx is the context that was passed to Start() and used when starting watches.
 	//
 	// According to the docs, contexts should not be stored in a struct: https://golang.org/pkg/context,
@@ -95,13 +108,94 @@ type Controller[request comparable] struct {
 
 	// LeaderElected indicates whether the controller is leader elected or always running.
 	LeaderElected *bool
+
+	// MaxRetries is the number of times a failing request is retried before being moved to DeadLetter,
+	// or dropped if DeadLetter is nil. Zero means retrying forever.
+	MaxRetries int
+
+	// DeadLetter stores the requests which failed with a terminal error or more than MaxRetries times.
+	// If nil, such requests are dropped.
+	DeadLetter deadletter.Store[request]
+
+	// Sharder, if set, restricts the controller to the requests owned by this replica.
+	Sharder *sharding.Sharder[request]
+
+	// Locker, if set, is used to acquire a cluster-wide lock on the requests before reconciling them.
+	Locker lease.Locker[request]
+
+	// lockBackoff delays the requests whose lock could not be acquired because of an error.
+	// It is distinct from the queue's rate limiter so that these failures do not count as retries.
+	lockBackoff workqueue.TypedRateLimiter[request]
+
+	// MetricsProvider creates the controller's metrics. Defaults to the prometheus vectors
+	// registered in metrics.Registry.
+	MetricsProvider metrics.MetricsProvider
+
+	metricsOnce  sync.Once
+	metricsCache *controllerMetrics
+
+	// TracerProvider, if set, is used to create a span per reconcile, linked to the spans
+	// recorded when the request was added to the queue with a context.
+	TracerProvider trace.TracerProvider
+
+	// DryRun, if set, records the results of the reconciles in the dry-run report,
+	// which is logged when the controller stops.
+	DryRun *dryrun.Client
+
+	// links holds the span contexts recorded when the requests were enqueued.
+	links     map[request][]trace.SpanContext
+	linksLock sync.Mutex
+
+	// eventTimes holds the time of the oldest event of the requests not reconciled yet.
+	eventTimes     map[request]time.Time
+	eventTimesLock sync.Mutex
+
+	// resumed is closed when the controller is resumed, it is nil while the controller is not paused.
+	resumed     chan struct{}
+	resumedLock sync.Mutex
+
+	// running is true while Start is running.
+	running atomic.Bool
+
+	// workersLock protects MaxConcurrentReconciles and the workers state once started.
+	workersLock sync.Mutex
+	workersCtx  context.Context
+	workersWG   *sync.WaitGroup
+	// workerStops holds the stop channel of each worker that was not asked to stop.
+	workerStops []chan struct{}
+	// runningWorkers is the number of workers that did not return yet.
+	runningWorkers int
+	// idle receives a worker each time it is waiting for an item,
+	// the dispatcher then dequeues an item and sends it to the worker.
+	idle chan *worker[request]
+	// dispatched is closed once the dispatcher returned, i.e. the queue is shut down.
+	dispatched chan struct{}
+
+	// latencySum and latencyCount track the reconcile latency for the autoscaler.
+	latencyLock  sync.Mutex
+	latencySum   time.Duration
+	latencyCount int
+
+	// activeWorkers is the number of workers currently reconciling a request.
+	activeWorkers atomic.Int64
+
+	// failures holds the failures of the requests that are currently failing.
+	failures     map[request]failure
+	failuresLock sync.Mutex
+}
+
+// failure records the consecutive failed reconciles of a request. Unlike the queue's NumRequeues,
+// it does not count the requeues requested by the reconciler.
+type failure struct {
+	first    time.Time
+	attempts int
 }
 
 // Reconcile implements reconcile.Reconciler.
 func (c *Controller[request]) Reconcile(ctx context.Context, req request) (_ reconcile.Result, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			ctrlmetrics.ReconcilePanics.WithLabelValues(c.Name).Inc()
+			c.metrics().reconcilePanics.Inc()
 
 			if c.RecoverPanic == nil || *c.RecoverPanic {
 				for _, fn := range utilruntime.PanicHandlers {
@@ -136,6 +230,11 @@ func (c *Controller[request]) Watch(src source.TypedSource[request]) error {
 	return src.Start(c.ctx, c.Queue)
 }
 
+// Synced implements controller.Controller.
+func (c *Controller[request]) Synced() bool {
+	return c.synced.Load()
+}
+
 // NeedLeaderElection implements the manager.LeaderElectionRunnable interface.
 func (c *Controller[request]) NeedLeaderElection() bool {
 	if c.LeaderElected == nil {
@@ -154,19 +253,40 @@ func (c *Controller[request]) Start(ctx context.Context) error {
 	}
 
 	c.initMetrics()
+	c.running.Store(true)
+	defer c.running.Store(false)
 
 	// Set the internal context.
 	c.ctx = ctx
 
 	queue := c.NewQueue(c.Name, c.RateLimiter)
+	// the queues restoring their items, e.g. the persistent queue, report the restore failures
+	if q, ok := queue.(interface{ Err() error }); ok {
+		if err := q.Err(); err != nil {
+			c.mu.Unlock()
+			queue.ShutDown()
+			return fmt.Errorf("failed to restore queue: %w", err)
+		}
+	}
 	if priorityQueue, isPriorityQueue := queue.(priorityqueue.PriorityQueue[request]); isPriorityQueue {
 		c.Queue = priorityQueue
 	} else {
 		c.Queue = &priorityQueueWrapper[request]{TypedRateLimitingInterface: queue}
 	}
+	c.Queue = &contextQueue[request]{PriorityQueue: c.Queue, c: c}
+	if c.Locker != nil {
+		c.lockBackoff = workqueue.NewTypedItemExponentialFailureRateLimiter[request](5*time.Millisecond, 1000*time.Second)
+	}
+	// Join the sharding group before starting the sources, so that they
+	// only enqueue the requests owned by this replica.
+	if c.Sharder != nil {
+		c.Queue = c.Sharder.Queue(c.Queue)
+		c.Do = c.Sharder.Reconciler(c.Do)
+	}
+	q := c.Queue
 	go func() {
 		<-ctx.Done()
-		c.Queue.ShutDown()
+		q.ShutDown()
 	}()
 
 	wg := &sync.WaitGroup{}
@@ -176,6 +296,12 @@ func (c *Controller[request]) Start(ctx context.Context) error {
 		// TODO(pwittrock): Reconsider HandleCrash
 		defer utilruntime.HandleCrash()
 
+		if c.Sharder != nil {
+			if err := c.Sharder.Start(ctx); err != nil {
+				return fmt.Errorf("failed to join sharding group: %w", err)
+			}
+		}
+
 		// NB(directxman12): launch the sources *before* trying to wait for the
 		// caches to sync so that they have a chance to register their intended
 		// caches.
@@ -245,19 +371,10 @@ func (c *Controller[request]) Start(ctx context.Context) error {
 		c.startWatches = nil
 
 		// Launch workers to process resources
-		c.LogConstructor(nil).Info("Starting workers", "worker count", c.MaxConcurrentReconciles)
-		wg.Add(c.MaxConcurrentReconciles)
-		for i := 0; i < c.MaxConcurrentReconciles; i++ {
-			go func() {
-				defer wg.Done()
-				// Run a worker thread that just dequeues items, processes them, and marks them done.
-				// It enforces that the reconcileHandler is never invoked concurrently with the same object.
-				for c.processNextWorkItem(ctx) {
-				}
-			}()
-		}
+		c.startWorkers(ctx, wg)
 
 		c.Started = true
+		c.synced.Store(true)
 		return nil
 	}()
 	if err != nil {
@@ -266,19 +383,30 @@ func (c *Controller[request]) Start(ctx context.Context) error {
 
 	<-ctx.Done()
 	c.LogConstructor(nil).Info("Shutdown signal received, waiting for all workers to finish")
+	c.stopWorkers()
 	wg.Wait()
 	c.LogConstructor(nil).Info("All workers finished")
+	if c.DryRun != nil {
+		c.DryRun.Report().Log(c.LogConstructor(nil))
+	}
 	return nil
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the reconcileHandler.
-func (c *Controller[request]) processNextWorkItem(ctx context.Context) bool {
-	obj, priority, shutdown := c.Queue.GetWithPriority()
-	if shutdown {
+// It returns false if the queue is shut down or if the worker is stopped.
+func (c *Controller[request]) processNextWorkItem(ctx context.Context, w *worker[request]) bool {
+	if !c.waitResumed(ctx, w.stop) {
+		return false
+	}
+	// The pause is only checked before dequeuing: an item dequeued while the controller
+	// is being paused is processed, holding it could lose it if the controller is stopped.
+	it, ok := c.next(ctx, w)
+	if !ok {
 		// Stop working
 		return false
 	}
+	obj, priority := it.obj, it.priority
 
 	// We call Done here so the workqueue knows we have finished
 	// processing this item. We also must remember to call Forget if we
@@ -288,8 +416,10 @@ func (c *Controller[request]) processNextWorkItem(ctx context.Context) bool {
 	// period.
 	defer c.Queue.Done(obj)
 
-	ctrlmetrics.ActiveWorkers.WithLabelValues(c.Name).Add(1)
-	defer ctrlmetrics.ActiveWorkers.WithLabelValues(c.Name).Add(-1)
+	c.metrics().activeWorkers.Add(1)
+	defer c.metrics().activeWorkers.Add(-1)
+	c.activeWorkers.Add(1)
+	defer c.activeWorkers.Add(-1)
 
 	c.reconcileHandler(ctx, obj, priority)
 	return true
@@ -300,18 +430,30 @@ const (
 	labelRequeueAfter = "requeue_after"
 	labelRequeue      = "requeue"
 	labelSuccess      = "success"
+	labelLocked       = "locked"
 )
 
 func (c *Controller[request]) initMetrics() {
-	ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelError).Add(0)
-	ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelRequeueAfter).Add(0)
-	ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelRequeue).Add(0)
-	ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelSuccess).Add(0)
-	ctrlmetrics.ReconcileErrors.WithLabelValues(c.Name).Add(0)
-	ctrlmetrics.TerminalReconcileErrors.WithLabelValues(c.Name).Add(0)
-	ctrlmetrics.ReconcilePanics.WithLabelValues(c.Name).Add(0)
-	ctrlmetrics.WorkerCount.WithLabelValues(c.Name).Set(float64(c.MaxConcurrentReconciles))
-	ctrlmetrics.ActiveWorkers.WithLabelValues(c.Name).Set(0)
+	m := c.metrics()
+	m.reconcileTotal[labelError].Add(0)
+	m.reconcileTotal[labelRequeueAfter].Add(0)
+	m.reconcileTotal[labelRequeue].Add(0)
+	m.reconcileTotal[labelSuccess].Add(0)
+	if c.Locker != nil {
+		m.reconcileTotal[labelLocked].Add(0)
+	}
+	m.reconcileErrors.Add(0)
+	m.terminalReconcileErrors.Add(0)
+	m.reconcilePanics.Add(0)
+	m.deadLettered.Add(0)
+	// set by the workers once started
+	m.workerCount.Set(0)
+	m.activeWorkers.Set(0)
+	if c.Paused() {
+		m.paused.Set(1)
+	} else {
+		m.paused.Set(0)
+	}
 }
 
 func (c *Controller[request]) reconcileHandler(ctx context.Context, req request, priority int) {
@@ -328,19 +470,74 @@ func (c *Controller[request]) reconcileHandler(ctx context.Context, req request,
 	ctx = logf.IntoContext(ctx, log)
 	ctx = addReconcileID(ctx, reconcileID)
 
+	var (
+		label   string
+		err     error
+		span    trace.Span
+		retried bool
+	)
+	ctx, span = c.startSpan(ctx, req, string(reconcileID), priority)
+	eventTime := c.takeEventTime(req)
+	defer func() {
+		c.endSpan(span, label, err)
+		c.observeEventTime(req, eventTime, retried)
+	}()
+
+	if c.Locker != nil {
+		var (
+			lctx       context.Context
+			unlock     func()
+			retryAfter time.Duration
+		)
+		lctx, unlock, retryAfter, err = c.Locker.TryLock(ctx, req)
+		if err != nil {
+			log.Error(err, "Failed to acquire lock")
+			c.Queue.AddWithOpts(priorityqueue.AddOpts{After: c.lockBackoff.When(req), Priority: priority}, req)
+			c.metrics().reconcileTotal[labelLocked].Inc()
+			label = labelLocked
+			retried = true
+			return
+		}
+		if unlock == nil {
+			log.V(5).Info(fmt.Sprintf("Lock held by another replica, requeueing after %s", retryAfter))
+			c.Queue.AddWithOpts(priorityqueue.AddOpts{After: retryAfter, Priority: priority}, req)
+			c.metrics().reconcileTotal[labelLocked].Inc()
+			label = labelLocked
+			retried = true
+			return
+		}
+		c.lockBackoff.Forget(req)
+		defer unlock()
+		ctx = lctx
+	}
+
 	// RunInformersAndControllers the syncHandler, passing it the Namespace/Name string of the
 	// resource to be synced.
 	log.V(5).Info("Reconciling")
 	result, err := c.Reconcile(ctx, req)
+	if err == nil {
+		c.clearFailure(req)
+	}
 	switch {
 	case err != nil:
-		if errors.Is(err, reconcile.TerminalError(nil)) {
-			ctrlmetrics.TerminalReconcileErrors.WithLabelValues(c.Name).Inc()
-		} else {
+		terminal := errors.Is(err, reconcile.TerminalError(nil))
+		if terminal {
+			c.metrics().terminalReconcileErrors.Inc()
+		}
+		switch {
+		case c.shouldDeadLetter(req, terminal):
+			c.deadLetter(ctx, req, priority, err, terminal)
+		case c.retriesExceeded(req):
+			log.Info("Dropping request, it failed too many times", "attempts", c.clearFailure(req).attempts+1)
+			c.Queue.Forget(req)
+		case !terminal:
+			c.recordFailure(req)
 			c.Queue.AddWithOpts(priorityqueue.AddOpts{RateLimited: true, Priority: priority}, req)
+			retried = true
 		}
-		ctrlmetrics.ReconcileErrors.WithLabelValues(c.Name).Inc()
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelError).Inc()
+		c.metrics().reconcileErrors.Inc()
+		c.metrics().reconcileTotal[labelError].Inc()
+		label = labelError
 		if !result.IsZero() {
 			log.Info("Warning: Reconciler returned both a non-zero result and a non-nil error. The result will always be ignored if the error is non-nil and the non-nil error causes requeuing with exponential backoff. For more details, see: https://pkg.go.dev/sigs.k8s.io/controller-runtime/pkg/reconcile#Reconciler")
 		}
@@ -353,18 +550,240 @@ func (c *Controller[request]) reconcileHandler(ctx context.Context, req request,
 		// to result.RequestAfter
 		c.Queue.Forget(req)
 		c.Queue.AddWithOpts(priorityqueue.AddOpts{After: result.RequeueAfter, Priority: priority}, req)
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelRequeueAfter).Inc()
+		c.metrics().reconcileTotal[labelRequeueAfter].Inc()
+		label = labelRequeueAfter
 	case result.Requeue:
 		log.V(5).Info("Reconcile done, requeueing")
 		c.Queue.AddWithOpts(priorityqueue.AddOpts{RateLimited: true, Priority: priority}, req)
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelRequeue).Inc()
+		c.metrics().reconcileTotal[labelRequeue].Inc()
+		label = labelRequeue
 	default:
 		log.V(5).Info("Reconcile successful")
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
 		c.Queue.Forget(req)
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, labelSuccess).Inc()
+		c.metrics().reconcileTotal[labelSuccess].Inc()
+		label = labelSuccess
+	}
+}
+
+func (c *Controller[request]) shouldDeadLetter(req request, terminal bool) bool {
+	if c.DeadLetter == nil {
+		return false
+	}
+	return terminal || c.retriesExceeded(req)
+}
+
+// retriesExceeded returns true if the failing request was retried MaxRetries times.
+func (c *Controller[request]) retriesExceeded(req request) bool {
+	if c.MaxRetries <= 0 {
+		return false
+	}
+	return c.currentFailure(req).attempts >= c.MaxRetries
+}
+
+// deadLetter moves the request to the dead-letter store. If it cannot be stored,
+// a non-terminal failing request is requeued as usual.
+func (c *Controller[request]) deadLetter(ctx context.Context, req request, priority int, err error, terminal bool) {
+	log := logf.FromContext(ctx)
+	now := time.Now()
+	f := c.currentFailure(req)
+	first := f.first
+	if first.IsZero() {
+		first = now
+	}
+	e := deadletter.Entry[request]{
+		Request:      req,
+		Error:        err.Error(),
+		Attempts:     f.attempts + 1,
+		Terminal:     terminal,
+		FirstFailure: first,
+		LastFailure:  now,
+	}
+	if err := c.DeadLetter.Put(ctx, e); err != nil {
+		log.Error(err, "Failed to move request to the dead-letter store")
+		if !terminal {
+			c.recordFailure(req)
+			c.Queue.AddWithOpts(priorityqueue.AddOpts{RateLimited: true, Priority: priority}, req)
+		}
+		return
+	}
+	c.clearFailure(req)
+	c.Queue.Forget(req)
+	c.metrics().deadLettered.Inc()
+	log.Info("Request moved to the dead-letter store", "attempts", e.Attempts)
+}
+
+// recordFailure records a failed reconcile of the request.
+func (c *Controller[request]) recordFailure(req request) {
+	c.failuresLock.Lock()
+	defer c.failuresLock.Unlock()
+	if c.failures == nil {
+		c.failures = make(map[request]failure)
+	}
+	f := c.failures[req]
+	if f.first.IsZero() {
+		f.first = time.Now()
+	}
+	f.attempts++
+	c.failures[req] = f
+}
+
+// currentFailure returns the failures of the request.
+func (c *Controller[request]) currentFailure(req request) failure {
+	c.failuresLock.Lock()
+	defer c.failuresLock.Unlock()
+	return c.failures[req]
+}
+
+// clearFailure forgets the failures of the request and returns them.
+func (c *Controller[request]) clearFailure(req request) failure {
+	c.failuresLock.Lock()
+	defer c.failuresLock.Unlock()
+	f := c.failures[req]
+	delete(c.failures, req)
+	return f
+}
+
+// Redrive implements controller.Controller.
+func (c *Controller[request]) Redrive(ctx context.Context, reqs ...request) error {
+	if c.DeadLetter == nil {
+		return errors.New("no dead-letter store configured")
+	}
+	if !c.isStarted() {
+		return errors.New("controller is not started")
+	}
+	for _, req := range reqs {
+		if err := c.DeadLetter.Delete(ctx, req); err != nil {
+			return fmt.Errorf("failed to remove %v from the dead-letter store: %w", req, err)
+		}
+		c.Queue.Add(req)
+	}
+	return nil
+}
+
+// Pause implements controller.Controller.
+func (c *Controller[request]) Pause() {
+	c.resumedLock.Lock()
+	defer c.resumedLock.Unlock()
+	if c.resumed != nil {
+		return
+	}
+	c.resumed = make(chan struct{})
+	c.metrics().paused.Set(1)
+	c.LogConstructor(nil).Info("Controller paused")
+}
+
+// Resume implements controller.Controller.
+func (c *Controller[request]) Resume() {
+	c.resumedLock.Lock()
+	defer c.resumedLock.Unlock()
+	if c.resumed == nil {
+		return
+	}
+	close(c.resumed)
+	c.resumed = nil
+	c.metrics().paused.Set(0)
+	c.LogConstructor(nil).Info("Controller resumed")
+}
+
+// Paused implements controller.Controller.
+func (c *Controller[request]) Paused() bool {
+	c.resumedLock.Lock()
+	defer c.resumedLock.Unlock()
+	return c.resumed != nil
+}
+
+// waitResumed blocks while the controller is paused.
+// It returns false if the context is done or stop is closed before the controller is resumed.
+func (c *Controller[request]) waitResumed(ctx context.Context, stop <-chan struct{}) bool {
+	c.resumedLock.Lock()
+	resumed := c.resumed
+	c.resumedLock.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// Status is the state of a Controller.
+type Status struct {
+	// Started is true while the controller is running.
+	Started bool
+	// Synced is true once the sources are synced and the workers started.
+	Synced bool
+	// Paused is true while the controller is paused.
+	Paused bool
+	// Workers is the number of workers.
+	Workers int
+	// ActiveWorkers is the number of workers currently reconciling a request.
+	ActiveWorkers int
+	// QueueDepth is the number of requests in the queue.
+	QueueDepth int
+}
+
+// Status implements controller.Controller.
+// It does not wait for the controller to be synced.
+func (c *Controller[request]) Status() Status {
+	s := Status{
+		Started:       c.running.Load(),
+		Synced:        c.synced.Load(),
+		Paused:        c.Paused(),
+		Workers:       c.Workers(),
+		ActiveWorkers: int(c.activeWorkers.Load()),
+	}
+	// the queue is only safe to access without holding mu once synced
+	if s.Synced {
+		s.QueueDepth = c.Queue.Len()
+	}
+	return s
+}
+
+// Inspect implements controller.Controller.
+func (c *Controller[request]) Inspect() ([]priorityqueue.ItemInfo[request], error) {
+	if !c.isStarted() {
+		return nil, errors.New("controller is not started")
+	}
+	return c.Queue.Snapshot(), nil
+}
+
+// Enqueue implements controller.Controller.
+func (c *Controller[request]) Enqueue(reqs ...request) error {
+	if !c.isStarted() {
+		return errors.New("controller is not started")
+	}
+	for _, req := range reqs {
+		c.Queue.Add(req)
+	}
+	return nil
+}
+
+// Forget implements controller.Controller.
+func (c *Controller[request]) Forget(reqs ...request) error {
+	if !c.isStarted() {
+		return errors.New("controller is not started")
+	}
+	c.Queue.Remove(reqs...)
+	for _, req := range reqs {
+		c.Queue.Forget(req)
+		c.clearFailure(req)
+		c.takeEventTime(req)
+		c.takeLinks(req)
 	}
+	return nil
+}
+
+func (c *Controller[request]) isStarted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Started
 }
 
 // GetLogger returns this controller's logger.
@@ -374,7 +793,8 @@ func (c *Controller[request]) GetLogger() logr.Logger {
 
 // updateMetrics updates prometheus metrics within the controller.
 func (c *Controller[request]) updateMetrics(reconcileTime time.Duration) {
-	ctrlmetrics.ReconcileTime.WithLabelValues(c.Name).Observe(reconcileTime.Seconds())
+	c.metrics().reconcileTime.Observe(reconcileTime.Seconds())
+	c.observeLatency(reconcileTime)
 }
 
 // ReconcileIDFromContext gets the reconcileID from the current context.
@@ -416,3 +836,11 @@ func (p *priorityQueueWrapper[request]) GetWithPriority() (request, int, bool) {
 	item, shutdown := p.TypedRateLimitingInterface.Get()
 	return item, 0, shutdown
 }
+
+// Snapshot returns nil, the content of the wrapped queue is not accessible.
+func (p *priorityQueueWrapper[request]) Snapshot() []priorityqueue.ItemInfo[request] {
+	return nil
+}
+
+// Remove does nothing, items cannot be removed from the wrapped queue.
+func (p *priorityQueueWrapper[request]) Remove(...request) {}
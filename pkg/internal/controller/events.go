@@ -0,0 +1,95 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+)
+
+// contextQueue records the events enqueued by the sources with their context:
+// the time of the first event not reconciled yet, and when tracing is enabled, an enqueue span
+// the reconcile span is linked to.
+type contextQueue[request comparable] struct {
+	priorityqueue.PriorityQueue[request]
+	c *Controller[request]
+}
+
+func (q *contextQueue[request]) AddWithContext(ctx context.Context, o priorityqueue.AddOpts, items ...request) {
+	at := o.EventTime
+	if at.IsZero() {
+		at = time.Now()
+	}
+	t := q.c.tracer()
+	for _, v := range items {
+		q.c.addEventTime(v, at)
+		if t == nil {
+			continue
+		}
+		_, span := t.Start(ctx, "Enqueue",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("controller.name", q.c.Name),
+				attribute.String("controller.key", fmt.Sprintf("%v", v)),
+				attribute.Int("controller.priority", o.Priority),
+			),
+		)
+		q.c.addLink(v, span.SpanContext())
+		span.End()
+	}
+	q.PriorityQueue.AddWithOpts(o, items...)
+}
+
+// addEventTime records the time of an event of the request, keeping the oldest one.
+func (c *Controller[request]) addEventTime(req request, t time.Time) {
+	c.eventTimesLock.Lock()
+	defer c.eventTimesLock.Unlock()
+	if c.eventTimes == nil {
+		c.eventTimes = make(map[request]time.Time)
+	}
+	if v, ok := c.eventTimes[req]; ok && v.Before(t) {
+		return
+	}
+	c.eventTimes[req] = t
+}
+
+// takeEventTime returns and removes the time of the oldest event of the request not reconciled yet.
+func (c *Controller[request]) takeEventTime(req request) time.Time {
+	c.eventTimesLock.Lock()
+	defer c.eventTimesLock.Unlock()
+	t := c.eventTimes[req]
+	delete(c.eventTimes, req)
+	return t
+}
+
+// observeEventTime observes the duration between the event and the end of the reconcile
+// which processed it. The events of requests retried, e.g. because the reconcile failed
+// or the lock was held by another replica, are kept until the request is processed.
+func (c *Controller[request]) observeEventTime(req request, t time.Time, retried bool) {
+	if t.IsZero() {
+		return
+	}
+	if retried {
+		c.addEventTime(req, t)
+		return
+	}
+	c.metrics().eventToReconcile.Observe(time.Since(t).Seconds())
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	ctrlmetrics "go.linka.cloud/protodb-controller/pkg/internal/controller/metrics"
+	"go.linka.cloud/protodb-controller/pkg/metrics"
+)
+
+// controllerMetrics holds the metrics of a controller created by its MetricsProvider.
+type controllerMetrics struct {
+	reconcileTotal          map[string]metrics.CounterMetric
+	reconcileErrors         metrics.CounterMetric
+	terminalReconcileErrors metrics.CounterMetric
+	reconcilePanics         metrics.CounterMetric
+	deadLettered            metrics.CounterMetric
+	reconcileTime           metrics.HistogramMetric
+	workerCount             metrics.GaugeMetric
+	activeWorkers           metrics.GaugeMetric
+	paused                  metrics.GaugeMetric
+	eventToReconcile        metrics.HistogramMetric
+}
+
+// metrics returns the controller's metrics, creating them on first use.
+func (c *Controller[request]) metrics() *controllerMetrics {
+	c.metricsOnce.Do(func() {
+		p := c.MetricsProvider
+		if p == nil {
+			p = ctrlmetrics.DefaultProvider
+		}
+		m := &controllerMetrics{
+			reconcileTotal:          make(map[string]metrics.CounterMetric),
+			reconcileErrors:         p.NewReconcileErrorsMetric(c.Name),
+			terminalReconcileErrors: p.NewTerminalReconcileErrorsMetric(c.Name),
+			reconcilePanics:         p.NewReconcilePanicsMetric(c.Name),
+			deadLettered:            p.NewDeadLetteredMetric(c.Name),
+			reconcileTime:           p.NewReconcileTimeMetric(c.Name),
+			workerCount:             p.NewWorkerCountMetric(c.Name),
+			activeWorkers:           p.NewActiveWorkersMetric(c.Name),
+			paused:                  p.NewPausedMetric(c.Name),
+			eventToReconcile:        p.NewEventToReconcileMetric(c.Name),
+		}
+		results := []string{labelError, labelRequeueAfter, labelRequeue, labelSuccess}
+		if c.Locker != nil {
+			results = append(results, labelLocked)
+		}
+		for _, v := range results {
+			m.reconcileTotal[v] = p.NewReconcileTotalMetric(c.Name, v)
+		}
+		c.metricsCache = m
+	})
+	return c.metricsCache
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 
@@ -27,69 +29,211 @@ var (
 	// ReconcileTotal is a prometheus counter metrics which holds the total
 	// number of reconciliations per controller. It has two labels. controller label refers
 	// to the controller name and result label refers to the reconcile result i.e
-	// success, error, requeue, requeue_after.
-	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "protodb_controller_reconcile_total",
-		Help: "Total number of reconciliations per controller",
-	}, []string{"controller", "result"})
+	// success, error, requeue, requeue_after, locked.
+	ReconcileTotal = defaultVectors.reconcileTotal
 
 	// ReconcileErrors is a prometheus counter metrics which holds the total
 	// number of errors from the Reconciler.
-	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "protodb_controller_reconcile_errors_total",
-		Help: "Total number of reconciliation errors per controller",
-	}, []string{"controller"})
+	ReconcileErrors = defaultVectors.reconcileErrors
 
 	// TerminalReconcileErrors is a prometheus counter metrics which holds the total
 	// number of terminal errors from the Reconciler.
-	TerminalReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "protodb_controller_terminal_reconcile_errors_total",
-		Help: "Total number of terminal reconciliation errors per controller",
-	}, []string{"controller"})
+	TerminalReconcileErrors = defaultVectors.terminalReconcileErrors
 
 	// ReconcilePanics is a prometheus counter metrics which holds the total
 	// number of panics from the Reconciler.
-	ReconcilePanics = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "protodb_controller_reconcile_panics_total",
-		Help: "Total number of reconciliation panics per controller",
-	}, []string{"controller"})
+	ReconcilePanics = defaultVectors.reconcilePanics
+
+	// DeadLetteredTotal is a prometheus counter metrics which holds the total
+	// number of requests moved to the dead-letter store.
+	DeadLetteredTotal = defaultVectors.deadLetteredTotal
 
 	// ReconcileTime is a prometheus metric which keeps track of the duration
 	// of reconciliations.
-	ReconcileTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name: "protodb_controller_reconcile_time_seconds",
-		Help: "Length of time per reconciliation per controller",
-		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4, 0.45, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0,
-			1.25, 1.5, 1.75, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5, 5, 6, 7, 8, 9, 10, 15, 20, 25, 30, 40, 50, 60},
-	}, []string{"controller"})
+	ReconcileTime = defaultVectors.reconcileTime
 
 	// WorkerCount is a prometheus metric which holds the number of
 	// concurrent reconciles per controller.
-	WorkerCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "protodb_controller_max_concurrent_reconciles",
-		Help: "Maximum number of concurrent reconciles per controller",
-	}, []string{"controller"})
+	WorkerCount = defaultVectors.workerCount
 
 	// ActiveWorkers is a prometheus metric which holds the number
 	// of active workers per controller.
-	ActiveWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "protodb_controller_active_workers",
-		Help: "Number of currently used workers per controller",
-	}, []string{"controller"})
+	ActiveWorkers = defaultVectors.activeWorkers
+
+	// Paused is a prometheus metric which is set to 1 while the controller is paused.
+	Paused = defaultVectors.paused
+
+	// EventToReconcile is a prometheus metric which keeps track of the duration between
+	// the enqueueing of a source event and the end of the reconcile which processed it.
+	EventToReconcile = defaultVectors.eventToReconcile
+
+	// WebhookRequests is a prometheus counter metrics which holds the total number of
+	// calls per webhook source, result and rejection reason.
+	WebhookRequests = defaultVectors.webhookRequests
 )
 
+// vectors holds the metrics vectors of the controllers.
+type vectors struct {
+	reconcileTotal          *prometheus.CounterVec
+	reconcileErrors         *prometheus.CounterVec
+	terminalReconcileErrors *prometheus.CounterVec
+	reconcilePanics         *prometheus.CounterVec
+	deadLetteredTotal       *prometheus.CounterVec
+	reconcileTime           *prometheus.HistogramVec
+	workerCount             *prometheus.GaugeVec
+	activeWorkers           *prometheus.GaugeVec
+	paused                  *prometheus.GaugeVec
+	eventToReconcile        *prometheus.HistogramVec
+	webhookRequests         *prometheus.CounterVec
+}
+
+func newVectors() *vectors {
+	return &vectors{
+		reconcileTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_reconcile_total",
+			Help: "Total number of reconciliations per controller",
+		}, []string{"controller", "result"}),
+		reconcileErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_reconcile_errors_total",
+			Help: "Total number of reconciliation errors per controller",
+		}, []string{"controller"}),
+		terminalReconcileErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_terminal_reconcile_errors_total",
+			Help: "Total number of terminal reconciliation errors per controller",
+		}, []string{"controller"}),
+		reconcilePanics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_reconcile_panics_total",
+			Help: "Total number of reconciliation panics per controller",
+		}, []string{"controller"}),
+		deadLetteredTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_dead_lettered_total",
+			Help: "Total number of requests moved to the dead-letter store per controller",
+		}, []string{"controller"}),
+		reconcileTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "protodb_controller_reconcile_time_seconds",
+			Help: "Length of time per reconciliation per controller",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4, 0.45, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0,
+				1.25, 1.5, 1.75, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5, 5, 6, 7, 8, 9, 10, 15, 20, 25, 30, 40, 50, 60},
+		}, []string{"controller"}),
+		workerCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "protodb_controller_max_concurrent_reconciles",
+			Help: "Maximum number of concurrent reconciles per controller",
+		}, []string{"controller"}),
+		activeWorkers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "protodb_controller_active_workers",
+			Help: "Number of currently used workers per controller",
+		}, []string{"controller"}),
+		paused: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "protodb_controller_paused",
+			Help: "Whether the controller is paused (1) or not (0)",
+		}, []string{"controller"}),
+		eventToReconcile: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:                            "protodb_controller_event_to_reconcile_seconds",
+			Help:                            "Time between the enqueueing of a source event and the end of the reconcile which processed it per controller",
+			Buckets:                         prometheus.ExponentialBuckets(0.005, 2, 16),
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: 1 * time.Hour,
+		}, []string{"controller"}),
+		webhookRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "protodb_controller_webhook_requests_total",
+			Help: "Total number of webhook calls per webhook, result (accepted or rejected) and rejection reason",
+		}, []string{"webhook", "result", "reason"}),
+	}
+}
+
+func (v *vectors) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		v.reconcileTotal,
+		v.reconcileErrors,
+		v.terminalReconcileErrors,
+		v.reconcilePanics,
+		v.deadLetteredTotal,
+		v.reconcileTime,
+		v.workerCount,
+		v.activeWorkers,
+		v.paused,
+		v.eventToReconcile,
+		v.webhookRequests,
+	}
+}
+
+var defaultVectors = newVectors()
+
 func init() {
+	metrics.Registry.MustRegister(defaultVectors.collectors()...)
 	metrics.Registry.MustRegister(
-		ReconcileTotal,
-		ReconcileErrors,
-		TerminalReconcileErrors,
-		ReconcilePanics,
-		ReconcileTime,
-		WorkerCount,
-		ActiveWorkers,
 		// expose process metrics like CPU, Memory, file descriptor usage etc.
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 		// expose Go runtime metrics like GC stats, memory stats etc.
 		collectors.NewGoCollector(),
 	)
 }
+
+// PrometheusProvider implements metrics.MetricsProvider with prometheus vectors.
+type PrometheusProvider struct {
+	v *vectors
+}
+
+var (
+	_ metrics.MetricsProvider        = (*PrometheusProvider)(nil)
+	_ metrics.WebhookMetricsProvider = (*PrometheusProvider)(nil)
+)
+
+// DefaultProvider reports the metrics to the vectors registered in metrics.Registry.
+var DefaultProvider = &PrometheusProvider{v: defaultVectors}
+
+// NewPrometheusProvider returns a PrometheusProvider reporting to new vectors registered in reg.
+func NewPrometheusProvider(reg prometheus.Registerer) (*PrometheusProvider, error) {
+	v := newVectors()
+	for _, c := range v.collectors() {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return &PrometheusProvider{v: v}, nil
+}
+
+func (p *PrometheusProvider) NewReconcileTotalMetric(controller, result string) metrics.CounterMetric {
+	return p.v.reconcileTotal.WithLabelValues(controller, result)
+}
+
+func (p *PrometheusProvider) NewReconcileErrorsMetric(controller string) metrics.CounterMetric {
+	return p.v.reconcileErrors.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewTerminalReconcileErrorsMetric(controller string) metrics.CounterMetric {
+	return p.v.terminalReconcileErrors.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewReconcilePanicsMetric(controller string) metrics.CounterMetric {
+	return p.v.reconcilePanics.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewDeadLetteredMetric(controller string) metrics.CounterMetric {
+	return p.v.deadLetteredTotal.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewReconcileTimeMetric(controller string) metrics.HistogramMetric {
+	return p.v.reconcileTime.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewWorkerCountMetric(controller string) metrics.GaugeMetric {
+	return p.v.workerCount.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewActiveWorkersMetric(controller string) metrics.GaugeMetric {
+	return p.v.activeWorkers.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewPausedMetric(controller string) metrics.GaugeMetric {
+	return p.v.paused.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewEventToReconcileMetric(controller string) metrics.HistogramMetric {
+	return p.v.eventToReconcile.WithLabelValues(controller)
+}
+
+func (p *PrometheusProvider) NewWebhookRequestsMetric(webhook, result, reason string) metrics.CounterMetric {
+	return p.v.webhookRequests.WithLabelValues(webhook, result, reason)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "go.linka.cloud/protodb-controller"
+
+	// maxLinks is the maximum number of enqueue spans a reconcile span is linked to,
+	// as the queue deduplicates the keys added while they are waiting.
+	maxLinks = 32
+)
+
+// tracer returns the controller's tracer, it is nil if tracing is disabled.
+func (c *Controller[request]) tracer() trace.Tracer {
+	if c.TracerProvider == nil {
+		return nil
+	}
+	return c.TracerProvider.Tracer(tracerName)
+}
+
+// startSpan starts the reconcile span, linked to the spans the request was enqueued in.
+func (c *Controller[request]) startSpan(ctx context.Context, req request, reconcileID string, priority int) (context.Context, trace.Span) {
+	t := c.tracer()
+	if t == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	var links []trace.Link
+	for _, v := range c.takeLinks(req) {
+		links = append(links, trace.Link{SpanContext: v})
+	}
+	return t.Start(ctx, "Reconcile",
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("controller.name", c.Name),
+			attribute.String("controller.key", fmt.Sprintf("%v", req)),
+			attribute.String("controller.reconcile_id", reconcileID),
+			attribute.Int("controller.priority", priority),
+		),
+	)
+}
+
+// endSpan records the result of the reconcile and ends the span.
+func (c *Controller[request]) endSpan(span trace.Span, result string, err error) {
+	if c.TracerProvider == nil {
+		return
+	}
+	span.SetAttributes(attribute.String("controller.result", result))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (c *Controller[request]) addLink(req request, sc trace.SpanContext) {
+	if !sc.IsValid() {
+		return
+	}
+	c.linksLock.Lock()
+	defer c.linksLock.Unlock()
+	if c.links == nil {
+		c.links = make(map[request][]trace.SpanContext)
+	}
+	links := append(c.links[req], sc)
+	if len(links) > maxLinks {
+		links = links[len(links)-maxLinks:]
+	}
+	c.links[req] = links
+}
+
+func (c *Controller[request]) takeLinks(req request) []trace.SpanContext {
+	c.linksLock.Lock()
+	defer c.linksLock.Unlock()
+	links := c.links[req]
+	delete(c.links, req)
+	return links
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+)
+
+// Autoscale configures the adaptive scaling of the workers.
+type Autoscale struct {
+	// MinWorkers is the minimum number of workers. Defaults to 1.
+	MinWorkers int
+	// MaxWorkers is the maximum number of workers. It is required.
+	MaxWorkers int
+	// Interval is the interval at which the number of workers is evaluated. Defaults to 10 seconds.
+	Interval time.Duration
+	// MaxLatency is the average reconcile latency above which the workers are scaled down,
+	// as adding workers to a saturated backend would only make it slower.
+	// Zero disables the latency based scaling.
+	MaxLatency time.Duration
+}
+
+// Validate validates the autoscaling configuration and sets its defaults.
+func (a *Autoscale) Validate() error {
+	if a.MinWorkers <= 0 {
+		a.MinWorkers = 1
+	}
+	if a.MaxWorkers < a.MinWorkers {
+		return fmt.Errorf("autoscale max workers (%d) must be greater than or equal to min workers (%d)", a.MaxWorkers, a.MinWorkers)
+	}
+	if a.Interval <= 0 {
+		a.Interval = 10 * time.Second
+	}
+	return nil
+}
+
+// SetWorkers implements controller.Controller.
+func (c *Controller[request]) SetWorkers(n int) error {
+	if n <= 0 {
+		return errors.New("the number of workers must be positive")
+	}
+	if c.Autoscale != nil {
+		return errors.New("the number of workers cannot be set when autoscaling is enabled")
+	}
+	c.setWorkers(n)
+	return nil
+}
+
+// Workers returns the number of workers.
+func (c *Controller[request]) Workers() int {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	return c.MaxConcurrentReconciles
+}
+
+func (c *Controller[request]) setWorkers(n int) {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	if n != c.MaxConcurrentReconciles {
+		c.LogConstructor(nil).Info("Updating worker count", "worker count", n)
+	}
+	c.MaxConcurrentReconciles = n
+	c.startWorkersLocked()
+}
+
+// startWorkers starts the workers, they run until ctx is done. wg is done once they all returned.
+func (c *Controller[request]) startWorkers(ctx context.Context, wg *sync.WaitGroup) {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	if c.Autoscale != nil {
+		c.MaxConcurrentReconciles = min(max(c.MaxConcurrentReconciles, c.Autoscale.MinWorkers), c.Autoscale.MaxWorkers)
+		go c.autoscale(ctx)
+	}
+	c.LogConstructor(nil).Info("Starting workers", "worker count", c.MaxConcurrentReconciles)
+	c.workersCtx = ctx
+	c.workersWG = wg
+	c.idle = make(chan *worker[request])
+	c.dispatched = make(chan struct{})
+	go c.dispatch(ctx)
+	c.startWorkersLocked()
+}
+
+// stopWorkers prevents new workers from being started, it must be called before waiting for the workers.
+func (c *Controller[request]) stopWorkers() {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	c.workersCtx = nil
+}
+
+// startWorkersLocked starts the missing workers and stops the workers in excess.
+// The stopped workers return once they are done with their current request, if any.
+func (c *Controller[request]) startWorkersLocked() {
+	if c.workersCtx == nil {
+		return
+	}
+	ctx := c.workersCtx
+	for len(c.workerStops) > c.MaxConcurrentReconciles {
+		last := len(c.workerStops) - 1
+		close(c.workerStops[last])
+		c.workerStops = c.workerStops[:last]
+	}
+	for len(c.workerStops) < c.MaxConcurrentReconciles {
+		stop := make(chan struct{})
+		c.workerStops = append(c.workerStops, stop)
+		c.runningWorkers++
+		c.metrics().workerCount.Set(float64(c.runningWorkers))
+		c.workersWG.Add(1)
+		w := &worker[request]{items: make(chan workItem[request]), stop: stop}
+		go func() {
+			defer c.workersWG.Done()
+			defer c.workerStopped()
+			// Run a worker thread that just dequeues items, processes them, and marks them done.
+			// It enforces that the reconcileHandler is never invoked concurrently with the same object.
+			for c.processNextWorkItem(ctx, w) {
+			}
+		}()
+	}
+}
+
+// workerStopped records that a worker returned.
+func (c *Controller[request]) workerStopped() {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	c.runningWorkers--
+	c.metrics().workerCount.Set(float64(c.runningWorkers))
+}
+
+type workItem[request comparable] struct {
+	obj      request
+	priority int
+}
+
+// worker receives the items dequeued by the dispatcher until stop is closed.
+type worker[request comparable] struct {
+	items chan workItem[request]
+	stop  <-chan struct{}
+}
+
+// dispatch dequeues the items for the idle workers until the queue is shut down or ctx is done.
+// It only dequeues an item once a worker is waiting for it, so that the items stay ordered by
+// priority in the queue while all the workers are busy, and the idle workers can be stopped.
+func (c *Controller[request]) dispatch(ctx context.Context) {
+	defer close(c.dispatched)
+	for {
+		var w *worker[request]
+		select {
+		case w = <-c.idle:
+		case <-ctx.Done():
+			return
+		}
+		obj, priority, shutdown := c.Queue.GetWithPriority()
+		if shutdown {
+			return
+		}
+		it := workItem[request]{obj: obj, priority: priority}
+		select {
+		case w.items <- it:
+		case <-w.stop:
+			// the worker was stopped after asking for an item
+			c.putBack(it)
+		case <-ctx.Done():
+			c.putBack(it)
+			return
+		}
+	}
+}
+
+// putBack puts back in the queue an item which was dequeued but not processed,
+// so that it is not lost, e.g. by a persistent queue.
+func (c *Controller[request]) putBack(it workItem[request]) {
+	c.Queue.AddWithOpts(priorityqueue.AddOpts{Priority: it.priority}, it.obj)
+	c.Queue.Done(it.obj)
+}
+
+// next waits for the dispatcher to dequeue an item for w. It returns false if the queue is shut down,
+// ctx is done or the worker is stopped.
+func (c *Controller[request]) next(ctx context.Context, w *worker[request]) (workItem[request], bool) {
+	select {
+	case c.idle <- w:
+	case <-c.dispatched:
+		return workItem[request]{}, false
+	case <-ctx.Done():
+		return workItem[request]{}, false
+	case <-w.stop:
+		return workItem[request]{}, false
+	}
+	select {
+	case it := <-w.items:
+		return it, true
+	case <-c.dispatched:
+		return workItem[request]{}, false
+	case <-ctx.Done():
+		// the priority queue does not return from GetWithPriority once shut down
+		return workItem[request]{}, false
+	case <-w.stop:
+		return workItem[request]{}, false
+	}
+}
+
+// observeLatency records the reconcile latency used by the autoscaler.
+func (c *Controller[request]) observeLatency(d time.Duration) {
+	if c.Autoscale == nil {
+		return
+	}
+	c.latencyLock.Lock()
+	defer c.latencyLock.Unlock()
+	c.latencySum += d
+	c.latencyCount++
+}
+
+// averageLatency returns the average reconcile latency since the last call.
+func (c *Controller[request]) averageLatency() (time.Duration, bool) {
+	c.latencyLock.Lock()
+	defer c.latencyLock.Unlock()
+	if c.latencyCount == 0 {
+		return 0, false
+	}
+	avg := c.latencySum / time.Duration(c.latencyCount)
+	c.latencySum, c.latencyCount = 0, 0
+	return avg, true
+}
+
+// autoscale periodically scales the workers between Autoscale.MinWorkers and Autoscale.MaxWorkers:
+// up while requests are waiting and all the workers are busy, down while workers are idle
+// or the reconcile latency is above Autoscale.MaxLatency.
+func (c *Controller[request]) autoscale(ctx context.Context) {
+	t := time.NewTicker(c.Autoscale.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if c.Paused() {
+			continue
+		}
+		n := c.Workers()
+		depth := c.Queue.Len()
+		active := int(c.activeWorkers.Load())
+		latency, ok := c.averageLatency()
+		switch {
+		case c.Autoscale.MaxLatency > 0 && ok && latency > c.Autoscale.MaxLatency:
+			n--
+		case depth > 0 && active >= n:
+			n = min(2*n, n+depth)
+		case depth == 0 && active < n/2:
+			n--
+		}
+		n = min(max(n, c.Autoscale.MinWorkers), c.Autoscale.MaxWorkers)
+		c.LogConstructor(nil).V(5).Info("Autoscaling workers", "worker count", n, "depth", depth, "active", active, "latency", latency)
+		c.setWorkers(n)
+	}
+}
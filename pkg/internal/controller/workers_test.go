@@ -0,0 +1,89 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/util/workqueue"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+func TestScaleDownWhileIdle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reconciled := make(chan string)
+	c := &Controller[string]{
+		Name:                    t.Name(),
+		MaxConcurrentReconciles: 4,
+		CacheSyncTimeout:        time.Second,
+		Do: reconcile.TypedFunc[string](func(ctx context.Context, req string) (reconcile.Result, error) {
+			select {
+			case reconciled <- req:
+			case <-ctx.Done():
+			}
+			return reconcile.Result{}, nil
+		}),
+		NewQueue: func(name string, _ workqueue.TypedRateLimiter[string]) workqueue.TypedRateLimitingInterface[string] {
+			return priorityqueue.New[string](name)
+		},
+		LogConstructor: func(*string) logr.Logger {
+			return logr.Discard()
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Start(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !c.Synced() {
+		if time.Now().After(deadline) {
+			t.Fatal("controller not synced")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// all the workers are idle: the one the dispatcher is waiting an item for may be stopped
+	for _, n := range []int{1, 3, 1} {
+		if err := c.SetWorkers(n); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		for i := range 5 {
+			key := fmt.Sprintf("%d-%d", n, i)
+			c.Queue.Add(key)
+			select {
+			case got := <-reconciled:
+				if got != key {
+					t.Fatalf("expected %s to be reconciled, got %s", key, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s not reconciled with %d workers", key, n)
+			}
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
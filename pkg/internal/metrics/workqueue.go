@@ -46,24 +46,24 @@ var (
 	depth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Subsystem: WorkQueueSubsystem,
 		Name:      DepthKey,
-		Help:      "Current depth of workqueue by workqueue and priority",
+		Help:      "Current depth of workqueue, by workqueue and priority",
 	}, []string{"name", "controller", "priority"})
 
 	adds = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Subsystem: WorkQueueSubsystem,
 		Name:      AddsKey,
-		Help:      "Total number of adds handled by workqueue",
-	}, []string{"name", "controller"})
+		Help:      "Total number of adds handled by workqueue, by workqueue and priority",
+	}, []string{"name", "controller", "priority"})
 
 	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Subsystem:                       WorkQueueSubsystem,
 		Name:                            QueueLatencyKey,
-		Help:                            "How long in seconds an item stays in workqueue before being requested",
+		Help:                            "How long in seconds an item stays in workqueue before being requested, by workqueue and priority",
 		Buckets:                         prometheus.ExponentialBuckets(10e-9, 10, 12),
 		NativeHistogramBucketFactor:     1.1,
 		NativeHistogramMaxBucketNumber:  100,
 		NativeHistogramMinResetDuration: 1 * time.Hour,
-	}, []string{"name", "controller"})
+	}, []string{"name", "controller", "priority"})
 
 	workDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Subsystem:                       WorkQueueSubsystem,
@@ -117,11 +117,11 @@ func (WorkqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetri
 }
 
 func (WorkqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
-	return adds.WithLabelValues(name, name)
+	return adds.WithLabelValues(name, name, "") // no priority
 }
 
 func (WorkqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
-	return latency.WithLabelValues(name, name)
+	return latency.WithLabelValues(name, name, "") // no priority
 }
 
 func (WorkqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
@@ -140,10 +140,14 @@ func (WorkqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterM
 	return retries.WithLabelValues(name, name)
 }
 
+// MetricsProviderWithPriority is a workqueue.MetricsProvider which is also able to
+// report the depth, adds and latency metrics for each priority.
 type MetricsProviderWithPriority interface {
 	workqueue.MetricsProvider
 
 	NewDepthMetricWithPriority(name string) DepthMetricWithPriority
+	NewAddsMetricWithPriority(name string) CounterMetricWithPriority
+	NewLatencyMetricWithPriority(name string) HistogramMetricWithPriority
 }
 
 // DepthMetricWithPriority represents a depth metric with priority.
@@ -152,12 +156,30 @@ type DepthMetricWithPriority interface {
 	Dec(priority int)
 }
 
+// CounterMetricWithPriority represents a counter metric with priority.
+type CounterMetricWithPriority interface {
+	Inc(priority int)
+}
+
+// HistogramMetricWithPriority represents a histogram metric with priority.
+type HistogramMetricWithPriority interface {
+	Observe(priority int, v float64)
+}
+
 var _ MetricsProviderWithPriority = WorkqueueMetricsProvider{}
 
 func (WorkqueueMetricsProvider) NewDepthMetricWithPriority(name string) DepthMetricWithPriority {
 	return &depthWithPriorityMetric{lvs: []string{name, name}}
 }
 
+func (WorkqueueMetricsProvider) NewAddsMetricWithPriority(name string) CounterMetricWithPriority {
+	return &addsWithPriorityMetric{lvs: []string{name, name}}
+}
+
+func (WorkqueueMetricsProvider) NewLatencyMetricWithPriority(name string) HistogramMetricWithPriority {
+	return &latencyWithPriorityMetric{lvs: []string{name, name}}
+}
+
 type depthWithPriorityMetric struct {
 	lvs []string
 }
@@ -169,3 +191,19 @@ func (g *depthWithPriorityMetric) Inc(priority int) {
 func (g *depthWithPriorityMetric) Dec(priority int) {
 	depth.WithLabelValues(append(g.lvs, strconv.Itoa(priority))...).Dec()
 }
+
+type addsWithPriorityMetric struct {
+	lvs []string
+}
+
+func (c *addsWithPriorityMetric) Inc(priority int) {
+	adds.WithLabelValues(append(c.lvs, strconv.Itoa(priority))...).Inc()
+}
+
+type latencyWithPriorityMetric struct {
+	lvs []string
+}
+
+func (h *latencyWithPriorityMetric) Observe(priority int, v float64) {
+	latency.WithLabelValues(append(h.lvs, strconv.Itoa(priority))...).Observe(v)
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lease provides cluster-wide per-request locks stored in protodb, allowing all the
+// replicas of a controller to be active while guaranteeing that a request is never reconciled
+// by two replicas at the same time.
+package lease
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.linka.cloud/protodb-controller/pkg/codec"
+	"go.linka.cloud/protodb-controller/pkg/pb"
+)
+
+// errConflict is returned when a lease transaction failed to commit,
+// most likely because another replica wrote the lease concurrently.
+var errConflict = errors.New("lease conflict")
+
+// Locker acquires cluster-wide locks on requests.
+type Locker[K comparable] interface {
+	// TryLock tries to acquire the lock of the request.
+	//
+	// If the lock is acquired, it returns a context derived from ctx which is cancelled if the lock
+	// is lost or could not be renewed before it expired, and the function releasing the lock.
+	// If the lock is held by someone else, it returns nil values and the duration after which
+	// the lock should be tried again.
+	TryLock(ctx context.Context, req K) (lctx context.Context, unlock func(), retryAfter time.Duration, err error)
+}
+
+// Opts contains the options for a protodb Locker.
+type Opts[K comparable] struct {
+	// Identity is the unique identity of the replica.
+	// Defaults to the hostname followed by a random suffix.
+	Identity string
+	// Codec encodes the requests to store them. Defaults to codec.JSON.
+	Codec codec.Codec[K]
+	// LeaseDuration is the duration after which a lease which was not renewed can be acquired
+	// by another replica. Defaults to 15 seconds.
+	LeaseDuration time.Duration
+	// RenewInterval is the interval at which the held leases are renewed.
+	// Defaults to a third of LeaseDuration.
+	RenewInterval time.Duration
+	Log           logr.Logger
+}
+
+// Opt allows to configure a protodb Locker.
+type Opt[K comparable] func(*Opts[K])
+
+// New returns a Locker storing the leases of the named controller's requests in db.
+func New[K comparable](ctx context.Context, db protodb.Client, controller string, o ...Opt[K]) (Locker[K], error) {
+	opts := &Opts[K]{}
+	for _, f := range o {
+		f(opts)
+	}
+	if opts.Identity == "" {
+		host, _ := os.Hostname()
+		opts.Identity = fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.JSON[K]{}
+	}
+	if opts.LeaseDuration <= 0 {
+		opts.LeaseDuration = 15 * time.Second
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.LeaseDuration / 3
+	}
+	if err := db.Register(ctx, pb.File_pb_lease_proto); err != nil {
+		return nil, err
+	}
+	return &locker[K]{
+		db:         typed.NewStore[pb.Lease](db),
+		controller: controller,
+		identity:   opts.Identity,
+		codec:      opts.Codec,
+		duration:   opts.LeaseDuration,
+		renew:      opts.RenewInterval,
+		log:        opts.Log.WithValues("controller", controller, "identity", opts.Identity),
+		now:        time.Now,
+	}, nil
+}
+
+type locker[K comparable] struct {
+	db         typed.Store[pb.Lease, *pb.Lease]
+	controller string
+	identity   string
+	codec      codec.Codec[K]
+	duration   time.Duration
+	renew      time.Duration
+	log        logr.Logger
+
+	// Configurable for testing
+	now func() time.Time
+}
+
+func (l *locker[K]) TryLock(ctx context.Context, req K) (context.Context, func(), time.Duration, error) {
+	id, err := l.id(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	expires := l.now().Add(l.duration)
+	retryAfter, err := l.acquire(ctx, id)
+	if errors.Is(err, errConflict) {
+		// most likely a conflict with another replica acquiring the lease,
+		// try again once it had a chance to complete
+		l.log.V(5).Info("Failed to acquire lease", "lease", id, "error", err.Error())
+		return nil, nil, l.renew, nil
+	}
+	if err != nil || retryAfter > 0 {
+		return nil, nil, retryAfter, err
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go l.keepAlive(lctx, cancel, id, expires, done)
+	unlock := func() {
+		cancel()
+		<-done
+		// the reconcile context may be done, use a fresh one to release the lease
+		ctx, cancel := context.WithTimeout(context.Background(), l.renew)
+		defer cancel()
+		if err := l.release(ctx, id); err != nil {
+			l.log.Error(err, "Failed to release lease", "lease", id)
+		}
+	}
+	return lctx, unlock, 0, nil
+}
+
+// acquire takes or renews the lease in a transaction, so that two replicas racing to
+// acquire the same lease conflict. It returns the remaining time of the lease if it
+// is held by another replica, and an error wrapping errConflict if the transaction failed to commit.
+func (l *locker[K]) acquire(ctx context.Context, id string) (time.Duration, error) {
+	tx, err := l.db.Tx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+	rs, _, err := tx.Get(ctx, &pb.Lease{ID: id})
+	if err != nil {
+		return 0, err
+	}
+	now := l.now()
+	if len(rs) != 0 && rs[0].Holder != l.identity {
+		if remaining := rs[0].ExpiresAt.AsTime().Sub(now); remaining > 0 {
+			return remaining, nil
+		}
+	}
+	if _, err := tx.Set(ctx, &pb.Lease{
+		ID:         id,
+		Controller: l.controller,
+		Holder:     l.identity,
+		ExpiresAt:  timestamppb.New(now.Add(l.duration)),
+	}); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %v", errConflict, err)
+	}
+	return 0, nil
+}
+
+// keepAlive renews the lease until ctx is done. It cancels ctx if the lease is held by another replica,
+// or if it could not be renewed before its expiration, as another replica may then acquire it.
+func (l *locker[K]) keepAlive(ctx context.Context, cancel context.CancelFunc, id string, expires time.Time, done chan<- struct{}) {
+	defer close(done)
+	renew := time.NewTimer(l.renew)
+	defer renew.Stop()
+	expiry := time.NewTimer(expires.Sub(l.now()))
+	defer expiry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expiry.C:
+			l.log.Info("Lease expired", "lease", id)
+			cancel()
+			return
+		case <-renew.C:
+		}
+		// the lease expires at the latest one lease duration after the renewal started
+		start := l.now()
+		retryAfter, err := l.acquire(ctx, id)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, errConflict):
+			l.log.V(5).Info("Failed to renew lease, retrying", "lease", id, "error", err.Error())
+			renew.Reset(l.renew / 4)
+		case err != nil:
+			l.log.Error(err, "Failed to renew lease, retrying", "lease", id)
+			renew.Reset(l.renew / 4)
+		case retryAfter > 0:
+			l.log.Info("Lease lost", "lease", id)
+			cancel()
+			return
+		default:
+			expiry.Reset(start.Add(l.duration).Sub(l.now()))
+			renew.Reset(l.renew)
+		}
+	}
+}
+
+func (l *locker[K]) release(ctx context.Context, id string) error {
+	tx, err := l.db.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	rs, _, err := tx.Get(ctx, &pb.Lease{ID: id})
+	if err != nil {
+		return err
+	}
+	if len(rs) == 0 || rs[0].Holder != l.identity {
+		return nil
+	}
+	if err := tx.Delete(ctx, rs[0]); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (l *locker[K]) id(req K) (string, error) {
+	b, err := l.codec.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", l.controller, base64.RawURLEncoding.EncodeToString(b)), nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+// CounterMetric represents a single numerical value that only ever goes up.
+type CounterMetric interface {
+	Inc()
+	Add(v float64)
+}
+
+// GaugeMetric represents a single numerical value that can go up and down.
+type GaugeMetric interface {
+	Set(v float64)
+	Add(v float64)
+}
+
+// HistogramMetric counts individual observations.
+type HistogramMetric interface {
+	Observe(v float64)
+}
+
+// MetricsProvider creates the metrics of the controllers, allowing to report them
+// to another registry or to another metrics system.
+//
+// The metrics of the controllers' queues are created by the workqueue.MetricsProvider
+// of the queues.
+type MetricsProvider interface {
+	// NewReconcileTotalMetric returns the counter of the reconciles of the controller
+	// with the given result, i.e. success, error, requeue, requeue_after or locked.
+	NewReconcileTotalMetric(controller, result string) CounterMetric
+	// NewReconcileErrorsMetric returns the counter of the reconcile errors of the controller.
+	NewReconcileErrorsMetric(controller string) CounterMetric
+	// NewTerminalReconcileErrorsMetric returns the counter of the terminal reconcile errors of the controller.
+	NewTerminalReconcileErrorsMetric(controller string) CounterMetric
+	// NewReconcilePanicsMetric returns the counter of the reconcile panics of the controller.
+	NewReconcilePanicsMetric(controller string) CounterMetric
+	// NewDeadLetteredMetric returns the counter of the requests moved to the dead-letter store.
+	NewDeadLetteredMetric(controller string) CounterMetric
+	// NewReconcileTimeMetric returns the histogram of the reconciles duration in seconds.
+	NewReconcileTimeMetric(controller string) HistogramMetric
+	// NewWorkerCountMetric returns the gauge of the number of workers of the controller.
+	NewWorkerCountMetric(controller string) GaugeMetric
+	// NewActiveWorkersMetric returns the gauge of the number of workers currently reconciling.
+	NewActiveWorkersMetric(controller string) GaugeMetric
+	// NewPausedMetric returns the gauge set to 1 while the controller is paused.
+	NewPausedMetric(controller string) GaugeMetric
+	// NewEventToReconcileMetric returns the histogram of the duration in seconds between the enqueueing of
+	// a source event and the end of the reconcile which processed it.
+	NewEventToReconcileMetric(controller string) HistogramMetric
+}
+
+// WebhookMetricsProvider creates the metrics of the webhook sources.
+type WebhookMetricsProvider interface {
+	// NewWebhookRequestsMetric returns the counter of the calls of the webhook with the given result,
+	// i.e. accepted or rejected, and rejection reason.
+	NewWebhookRequestsMetric(webhook, result, reason string) CounterMetric
+}
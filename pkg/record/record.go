@@ -0,0 +1,155 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package record provides the recording of the events seen by a controller's source and of
+// the resulting reconciles, and their replay against a reconciler, allowing to reproduce
+// deterministically the sequence of reconciles which led to an incident.
+//
+// Recordings are JSON lines: each line is an Entry, either a source event or a reconcile.
+// The keys are encoded with encoding/json and the objects with protojson.
+//
+// A controller records its events and reconciles with the controller.WithRecorder source option:
+//
+//	f, _ := os.Create("events.jsonl")
+//	rec := record.NewRecorder(f)
+//	c, _ := controller.New(name, db, key, options, controller.WithRecorder[pb.Resource](rec))
+//
+// The recordings are replayed in tests with Replay, or in dry-run with the command of the replaycmd package.
+package record
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+// Entry is a line of a recording.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Key is the JSON encoded key of the event or of the reconcile.
+	Key json.RawMessage `json:"key"`
+	// Event is the type of the source event, e.g. create or update. It is empty for the reconciles.
+	Event string `json:"event,omitempty"`
+	// Old is the protojson encoded previous version of the object, it is only set for the update and delete events.
+	Old json.RawMessage `json:"old,omitempty"`
+	// New is the protojson encoded current version of the object, it is not set for the delete events.
+	New json.RawMessage `json:"new,omitempty"`
+	// Reconcile is the result of the reconcile, it is only set for the reconciles.
+	Reconcile *Reconcile `json:"reconcile,omitempty"`
+}
+
+// IsEvent returns true if the entry is a source event.
+func (e *Entry) IsEvent() bool {
+	return e.Reconcile == nil
+}
+
+// Reconcile is the result of a reconcile.
+type Reconcile struct {
+	Requeue      bool          `json:"requeue,omitempty"`
+	RequeueAfter time.Duration `json:"requeueAfter,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// NewReconcile returns the Reconcile of a reconciler's result.
+func NewReconcile(res reconcile.Result, err error) Reconcile {
+	r := Reconcile{Requeue: res.Requeue, RequeueAfter: res.RequeueAfter}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Recorder writes the entries of a recording. It is safe for concurrent use.
+//
+// The recording errors do not interrupt the controller: the first one is kept and returned by Err,
+// and the following entries are dropped.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+	err error
+}
+
+// NewRecorder returns a Recorder writing the entries to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// RecordEvent records a source event of the given type for the key.
+func (r *Recorder) RecordEvent(event string, key any, old, new proto.Message) {
+	e := Entry{Event: event}
+	var err error
+	if e.Key, err = json.Marshal(key); err != nil {
+		r.fail(err)
+		return
+	}
+	if e.Old, err = marshal(old); err != nil {
+		r.fail(err)
+		return
+	}
+	if e.New, err = marshal(new); err != nil {
+		r.fail(err)
+		return
+	}
+	r.write(e)
+}
+
+// RecordReconcile records the result of the reconcile of the key.
+func (r *Recorder) RecordReconcile(key any, res reconcile.Result, err error) {
+	k, merr := json.Marshal(key)
+	if merr != nil {
+		r.fail(merr)
+		return
+	}
+	rec := NewReconcile(res, err)
+	r.write(Entry{Key: k, Reconcile: &rec})
+}
+
+// Err returns the first error encountered while recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	e.Time = r.now()
+	r.err = r.enc.Encode(e)
+}
+
+func (r *Recorder) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func marshal(m proto.Message) (json.RawMessage, error) {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil, nil
+	}
+	return protojson.Marshal(m)
+}
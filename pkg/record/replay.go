@@ -0,0 +1,145 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+	"go.linka.cloud/protodb-controller/pkg/store"
+)
+
+// ReplayOpts contains the options of Replay.
+type ReplayOpts struct {
+	// OnEvent is called with the source events of the recording, in order, e.g. to apply
+	// the recorded objects to the store read by the reconciler. See Apply.
+	OnEvent func(ctx context.Context, e Entry) error
+}
+
+// ReplayOpt allows to configure Replay.
+type ReplayOpt func(*ReplayOpts)
+
+// WithOnEvent sets the function called with the source events of the recording.
+func WithOnEvent(fn func(ctx context.Context, e Entry) error) ReplayOpt {
+	return func(o *ReplayOpts) {
+		o.OnEvent = fn
+	}
+}
+
+// Report is the result of a replay.
+type Report[K comparable] struct {
+	// Events is the number of source events replayed.
+	Events int
+	// Reconciles is the number of reconciles replayed.
+	Reconciles int
+	// Mismatches are the reconciles whose result differ from the recorded one.
+	Mismatches []Mismatch[K]
+}
+
+// Mismatch is a replayed reconcile whose result differ from the recorded one.
+type Mismatch[K comparable] struct {
+	// Line is the line of the reconcile in the recording.
+	Line     int
+	Key      K
+	Recorded Reconcile
+	Replayed Reconcile
+}
+
+// Replay reads the recording from rd and calls the reconciler with the keys of the recorded
+// reconciles, in order and sequentially. The source events are passed to the ReplayOpts.OnEvent
+// function so that the reconciler sees the same objects as when the recording was made.
+//
+// The results which differ from the recorded ones are reported as mismatches. Replay fails if the
+// recording cannot be read, if OnEvent fails or if ctx is done.
+func Replay[K comparable](ctx context.Context, rd io.Reader, r reconcile.TypedReconciler[K], o ...ReplayOpt) (*Report[K], error) {
+	opts := ReplayOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	report := &Report[K]{}
+	s := bufio.NewScanner(rd)
+	s.Buffer(nil, 16<<20)
+	for line := 1; s.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.IsEvent() {
+			report.Events++
+			if opts.OnEvent == nil {
+				continue
+			}
+			if err := opts.OnEvent(ctx, e); err != nil {
+				return report, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+		var key K
+		if err := json.Unmarshal(e.Key, &key); err != nil {
+			return report, fmt.Errorf("line %d: decode key: %w", line, err)
+		}
+		report.Reconciles++
+		res, err := r.Reconcile(ctx, key)
+		if got := NewReconcile(res, err); got != *e.Reconcile {
+			report.Mismatches = append(report.Mismatches, Mismatch[K]{Line: line, Key: key, Recorded: *e.Reconcile, Replayed: got})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// Apply returns an OnEvent function writing the objects of the recorded events to s:
+// the new version of the object is set, or the old one deleted for the delete events.
+func Apply[T any, PT typed.Message[T]](s store.Store[T, PT]) func(ctx context.Context, e Entry) error {
+	return func(ctx context.Context, e Entry) error {
+		if e.New == nil {
+			old, err := Decode[T, PT](e.Old)
+			if err != nil {
+				return err
+			}
+			return s.Delete(ctx, old)
+		}
+		m, err := Decode[T, PT](e.New)
+		if err != nil {
+			return err
+		}
+		_, err = s.Set(ctx, m)
+		return err
+	}
+}
+
+// Decode decodes a recorded object.
+func Decode[T any, PT typed.Message[T]](b json.RawMessage) (PT, error) {
+	var m PT = new(T)
+	if err := protojson.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replaycmd implements the dry-run replay command of the recordings made with the record package.
+//
+// The reconcilers are compiled with the applications, so that no generic binary can replay them:
+// the application writes the main package of its replay command, calling Main with its reconciler,
+// e.g. in cmd/replay/main.go:
+//
+//	func main() {
+//		replaycmd.Main[string, pb.Resource](func(db protodb.Client) reconcile.TypedReconciler[string] {
+//			return NewReconciler(typed.NewStore[pb.Resource](db))
+//		})
+//	}
+//
+// and runs it with the recording of an incident:
+//
+//	go run ./cmd/replay -f events.jsonl -v
+//
+// The recorded events are applied to an in-memory database, and the reconciler reads it through
+// a dry-run client, so that its writes are reported instead of being applied: the objects it wrote
+// while the recording was made are applied from their recorded events.
+// The command prints the reconciles whose result differ from the recorded one and the dry-run report,
+// and exits with the status 1 if any reconcile differs.
+package replaycmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+
+	"go.linka.cloud/protodb-controller/pkg/dryrun"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+	"go.linka.cloud/protodb-controller/pkg/record"
+	"go.linka.cloud/protodb-controller/pkg/store"
+)
+
+// ErrMismatch is returned by Run when replayed reconciles differ from the recorded ones.
+var ErrMismatch = errors.New("replayed reconciles differ from the recording")
+
+// NewReconcilerFunc returns the reconciler to replay, reading and writing db.
+type NewReconcilerFunc[K comparable] func(db protodb.Client) reconcile.TypedReconciler[K]
+
+// Main runs the command with the program's arguments, and exits with the status 1 if it fails.
+func Main[K comparable, T any, PT typed.Message[T]](fn NewReconcilerFunc[K]) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := Run[K, T, PT](ctx, os.Args[0], os.Args[1:], os.Stdout, fn)
+	cancel()
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run parses the command line arguments, replays the recording against the reconciler
+// returned by fn and writes the report to w.
+func Run[K comparable, T any, PT typed.Message[T]](ctx context.Context, name string, args []string, w io.Writer, fn NewReconcilerFunc[K]) error {
+	if fn == nil {
+		return errors.New("reconciler is required")
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("f", "", "the recording to replay, - for the standard input")
+	verbose := fs.Bool("v", false, "print the writes of the reconciler")
+	maxWrites := fs.Int("max-writes", dryrun.DefaultMaxWrites, "the number of writes kept in the report, -1 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("recording is required")
+	}
+	var rd io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rd = f
+	}
+	db, err := protodb.Open(ctx, protodb.WithInMemory(true))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	var z PT
+	if err := db.Register(ctx, z.ProtoReflect().Descriptor().ParentFile()); err != nil {
+		return fmt.Errorf("failed to register %s: %w", z.ProtoReflect().Descriptor().FullName(), err)
+	}
+	dry := dryrun.New(db, dryrun.WithMaxWrites(*maxWrites))
+	r := dryrun.Reconciler(dry, fn(dry))
+	report, err := record.Replay(ctx, rd, r, record.WithOnEvent(record.Apply(store.New[T, PT](db))))
+	if err != nil {
+		return fmt.Errorf("failed to replay %s: %w", *file, err)
+	}
+	printReport(w, report, dry.Report(), *verbose)
+	if len(report.Mismatches) != 0 {
+		return fmt.Errorf("%w: %d of %d reconciles", ErrMismatch, len(report.Mismatches), report.Reconciles)
+	}
+	return nil
+}
+
+func printReport[K comparable](w io.Writer, report *record.Report[K], dry dryrun.Report, verbose bool) {
+	fmt.Fprintf(w, "Replayed %d events and %d reconciles\n", report.Events, report.Reconciles)
+	for _, m := range report.Mismatches {
+		fmt.Fprintf(w, "line %d: %v: recorded %s, replayed %s\n", m.Line, m.Key, result(m.Recorded), result(m.Replayed))
+	}
+	fmt.Fprintf(w, "Writes: %d sets, %d deletes, %d commits\n", dry.Sets(), dry.Deletes(), dry.Commits)
+	for _, k := range slices.Sorted(maps.Keys(dry.Types)) {
+		fmt.Fprintf(w, "  %s: %d sets, %d deletes\n", k, dry.Types[k].Sets, dry.Types[k].Deletes)
+	}
+	if !verbose {
+		return
+	}
+	for _, v := range dry.Writes {
+		fmt.Fprintf(w, "%s %s\n", v.Op, v.Type)
+		for _, c := range v.Changes {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	if dry.DroppedWrites != 0 {
+		fmt.Fprintf(w, "%d writes not kept, see -max-writes\n", dry.DroppedWrites)
+	}
+}
+
+func result(r record.Reconcile) string {
+	switch {
+	case r.Error != "":
+		return fmt.Sprintf("error %q", r.Error)
+	case r.RequeueAfter > 0:
+		return fmt.Sprintf("requeue after %s", r.RequeueAfter)
+	case r.Requeue:
+		return "requeue"
+	default:
+		return "success"
+	}
+}
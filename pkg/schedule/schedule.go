@@ -0,0 +1,317 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schedule parses cron expressions.
+//
+// The expressions use the standard five fields: minute, hour, day of month, month and day of week.
+// Each field is either *, a value, a range (1-5), a list (1,3,5) or a step (*/15, 0-30/10, 5/10).
+// Months and days of week can also be given by their three letters English name (jan, mon),
+// and Sunday is either 0 or 7, e.g. mon-sun. As with Vixie cron, when both the day of month and the day of week
+// are restricted, i.e. do not start with *, the schedule matches the days matching either of them.
+//
+// The descriptors @yearly (or @annually), @monthly, @weekly, @daily (or @midnight) and @hourly are supported,
+// as well as @every <duration>, e.g. @every 1h30m, for fixed intervals.
+// The expressions are evaluated in the location of the times passed to Next, unless they are prefixed
+// by CRON_TZ=<location>, e.g. CRON_TZ=Europe/Paris 0 9 * * mon-fri.
+// When a daylight saving change sets the clock back, the wall clock times repeated are only activated once,
+// unless the minute or the hour field starts with *. The times skipped when the clock is set forward are not activated.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule interface {
+	// Next returns the first activation time strictly after t,
+	// or the zero time if the schedule never activates.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	var loc *time.Location
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		tz, rest, _ := strings.Cut(expr, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("invalid location %q: %w", name, err)
+		}
+		expr = strings.TrimSpace(rest)
+	}
+	if strings.HasPrefix(expr, "@") {
+		return parseDescriptor(expr, loc)
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	s := &cron{loc: loc}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], daysOfMonth); err != nil {
+		return nil, fmt.Errorf("invalid day of month field: %w", err)
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], daysOfWeek); err != nil {
+		return nil, fmt.Errorf("invalid day of week field: %w", err)
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = isAny(fields[2])
+	s.anyDow = isAny(fields[4])
+	s.wildcard = strings.HasPrefix(fields[0], "*") || strings.HasPrefix(fields[1], "*")
+	return s, nil
+}
+
+// MustParse is like Parse but panics if the expression cannot be parsed.
+func MustParse(expr string) Schedule {
+	s, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseDescriptor(expr string, loc *time.Location) (Schedule, error) {
+	switch expr {
+	case "@yearly", "@annually":
+		return Parse(withTZ("0 0 1 1 *", loc))
+	case "@monthly":
+		return Parse(withTZ("0 0 1 * *", loc))
+	case "@weekly":
+		return Parse(withTZ("0 0 * * 0", loc))
+	case "@daily", "@midnight":
+		return Parse(withTZ("0 0 * * *", loc))
+	case "@hourly":
+		return Parse(withTZ("0 * * * *", loc))
+	}
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if every <= 0 {
+			return nil, errors.New("invalid @every duration: must be positive")
+		}
+		return Every(every), nil
+	}
+	return nil, fmt.Errorf("unknown descriptor %q", expr)
+}
+
+func withTZ(expr string, loc *time.Location) string {
+	if loc == nil {
+		return expr
+	}
+	return "CRON_TZ=" + loc.String() + " " + expr
+}
+
+// Every returns a schedule activating at a fixed interval.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+	// ends are the values of the names ending a range before its start, e.g. sun in mon-sun.
+	ends map[string]int
+}
+
+var (
+	minutes     = bounds{min: 0, max: 59}
+	hours       = bounds{min: 0, max: 23}
+	daysOfMonth = bounds{min: 1, max: 31}
+	months      = bounds{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	daysOfWeek = bounds{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}, ends: map[string]int{"sun": 7}}
+)
+
+// isAny reports whether the day field is unrestricted for the union of the day of month and the day of week:
+// as with Vixie cron, it is any field starting with *, e.g. */2.
+func isAny(field string) bool {
+	return strings.HasPrefix(field, "*") || field == "?"
+}
+
+// parseField returns the bitset of the values matched by the field.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			l, h, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(l, b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(h, b); err != nil {
+				return 0, err
+			}
+			if v, ok := b.ends[strings.ToLower(h)]; ok && hi < lo {
+				hi = v
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := parseValue(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// a single value with a step, e.g. 5/10, ranges until the maximum
+			if !hasStep {
+				hi = v
+			}
+		}
+		n := 1
+		if hasStep {
+			var err error
+			if n, err = strconv.Atoi(step); err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", step)
+			}
+		}
+		for v := lo; v <= hi; v += n {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool
+	// wildcard is true when the minute or the hour field starts with *,
+	// in which case the schedule also activates in the wall clock times repeated by a daylight saving change.
+	wildcard bool
+	loc      *time.Location
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	orig := t.Location()
+	if c.loc != nil {
+		t = t.In(c.loc)
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// five years is enough to find the next activation of any valid schedule, e.g. the 29th of February
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = after(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !c.matchDay(t) {
+			t = after(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = after(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		// as with Vixie cron, the fixed times are not activated twice when the clock is set back
+		if end, ok := repeated(t); ok && !c.wildcard {
+			t = end
+			continue
+		}
+		return t.In(orig)
+	}
+	return time.Time{}
+}
+
+// after returns next, or the start of the next hour if next is not after t: when the wall clock time
+// of next is skipped by a daylight saving change, time.Date may return a time before it.
+func after(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Truncate(time.Hour).Add(time.Hour)
+}
+
+// repeated reports whether the wall clock time of t already occurred before a daylight saving change
+// setting the clock back, and returns the end of the repeated times.
+func repeated(t time.Time) (time.Time, bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, before := start.Add(-time.Second).Zone()
+	_, offset := t.Zone()
+	if before <= offset {
+		return time.Time{}, false
+	}
+	end := start.Add(time.Duration(before-offset) * time.Second)
+	return end, t.Before(end)
+}
+
+func (c *cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Monday
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{expr: "0 9 * * *", from: monday, want: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * *", from: monday, want: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{expr: "1,2 3 * * *", from: monday.Add(3*time.Hour + time.Minute), want: time.Date(2026, 10, 19, 3, 2, 0, 0, time.UTC)},
+		// ranges and steps
+		{expr: "*/15 * * * *", from: monday.Add(10*time.Hour + 7*time.Minute), want: time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{expr: "5/20 * * * *", from: monday.Add(10*time.Hour + 30*time.Minute), want: time.Date(2026, 10, 19, 10, 45, 0, 0, time.UTC)},
+		{expr: "0-30/10 10 * * *", from: monday.Add(10*time.Hour + 31*time.Minute), want: time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)},
+		{expr: "0 9-17 * * *", from: monday.Add(17*time.Hour + time.Minute), want: time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		// names
+		{expr: "0 0 1 jan *", from: monday, want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * sat-sun", from: monday, want: time.Date(2026, 10, 24, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * MON-FRI", from: monday.Add(10 * time.Hour), want: time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		// Sunday is both 0 and 7
+		{expr: "0 9 * * 0", from: monday, want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * 7", from: monday, want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * sun", from: monday, want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * sun-sun", from: monday, want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * sun-mon", from: monday.Add(10 * time.Hour), want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * fri-sun", from: monday, want: time.Date(2026, 10, 23, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * mon-sun", from: monday.Add(10 * time.Hour), want: time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		// the day of month and the day of week match either of them when both are restricted
+		{expr: "0 0 13 * fri", from: monday, want: time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 20 * fri", from: monday, want: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 */2 * mon", from: monday, want: time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 13 * *", from: monday, want: time.Date(2026, 11, 13, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 feb *", from: monday, want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// descriptors and locations
+		{expr: "@daily", from: monday, want: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{expr: "@every 90m", from: monday, want: time.Date(2026, 10, 19, 1, 30, 0, 0, time.UTC)},
+		{expr: "CRON_TZ=America/New_York 0 9 * * *", from: monday, want: time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)},
+		// the fixed times repeated when the clock is set back are activated once
+		{expr: "30 1 * * *", from: time.Date(2025, 11, 2, 0, 0, 0, 0, ny), want: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC)},
+		{expr: "30 1 * * *", from: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC).In(ny), want: time.Date(2025, 11, 3, 6, 30, 0, 0, time.UTC)},
+		// unless the minute or the hour field starts with *
+		{expr: "*/30 * * * *", from: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC).In(ny), want: time.Date(2025, 11, 2, 6, 0, 0, 0, time.UTC)},
+		{expr: "30 * * * *", from: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC).In(ny), want: time.Date(2025, 11, 2, 6, 30, 0, 0, time.UTC)},
+		// the times skipped when the clock is set forward are not activated
+		{expr: "30 2 * * *", from: time.Date(2026, 3, 8, 0, 0, 0, 0, ny), want: time.Date(2026, 3, 9, 6, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s from %s: expected %s, got %s", tt.expr, tt.from, tt.want, got.UTC())
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"* * * * fri-mon",
+		"*/0 * * * *",
+		"* * * * foo",
+		"@every -1m",
+		"@unknown",
+		"CRON_TZ=Nowhere/Nothing * * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
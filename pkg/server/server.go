@@ -0,0 +1,207 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server provides an HTTP server exposing the controllers metrics,
+// liveness and readiness endpoints.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go.linka.cloud/protodb-controller/pkg/healthz"
+	logf "go.linka.cloud/protodb-controller/pkg/internal/log"
+	"go.linka.cloud/protodb-controller/pkg/metrics"
+)
+
+var log = logf.RuntimeLog.WithName("server")
+
+const (
+	// DefaultBindAddress is the default address the server listens on.
+	DefaultBindAddress = ":8080"
+
+	metricsPath = "/metrics"
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// Options are the options of the Server.
+type Options struct {
+	// BindAddress is the TCP address the server listens on. Defaults to DefaultBindAddress.
+	BindAddress string
+
+	// Gatherer gathers the metrics served on /metrics. Defaults to metrics.Registry.
+	Gatherer prometheus.Gatherer
+
+	// ShutdownTimeout is the maximum duration given to the server to shut down
+	// gracefully once the context is done. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+
+	// Controllers are the controllers by name whose sync state is checked on /readyz,
+	// each one with a healthz.SyncedCheck named after it.
+	Controllers map[string]healthz.Synced
+}
+
+// Opt allows to configure the Server.
+type Opt func(*Options)
+
+// WithControllers adds readiness checks failing until the controllers are synced.
+func WithControllers(controllers map[string]healthz.Synced) Opt {
+	return func(o *Options) {
+		if o.Controllers == nil {
+			o.Controllers = make(map[string]healthz.Synced, len(controllers))
+		}
+		maps.Copy(o.Controllers, controllers)
+	}
+}
+
+// Server serves the metrics gathered by Options.Gatherer on /metrics, and the health checks
+// on /healthz and /readyz. The controllers' sync state is checked on /readyz with WithControllers,
+// and readiness checks should be added for any other condition required to serve traffic,
+// e.g. the leadership state being known.
+type Server struct {
+	opts Options
+
+	mu       sync.Mutex
+	healthz  map[string]healthz.Checker
+	readyz   map[string]healthz.Checker
+	handlers map[string]http.Handler
+	started  bool
+}
+
+// New returns a new Server.
+func New(o Options, opts ...Opt) *Server {
+	for _, f := range opts {
+		f(&o)
+	}
+	if o.BindAddress == "" {
+		o.BindAddress = DefaultBindAddress
+	}
+	if o.Gatherer == nil {
+		o.Gatherer = metrics.Registry
+	}
+	if o.ShutdownTimeout == 0 {
+		o.ShutdownTimeout = 30 * time.Second
+	}
+	s := &Server{
+		opts:     o,
+		healthz:  map[string]healthz.Checker{},
+		readyz:   map[string]healthz.Checker{},
+		handlers: map[string]http.Handler{},
+	}
+	for name, c := range o.Controllers {
+		s.readyz[name] = healthz.SyncedCheck(c)
+	}
+	return s
+}
+
+// AddHealthzCheck adds a liveness check served on /healthz.
+func (s *Server) AddHealthzCheck(name string, check healthz.Checker) error {
+	return s.addCheck(s.healthz, name, check)
+}
+
+// AddReadyzCheck adds a readiness check served on /readyz.
+func (s *Server) AddReadyzCheck(name string, check healthz.Checker) error {
+	return s.addCheck(s.readyz, name, check)
+}
+
+func (s *Server) addCheck(checks map[string]healthz.Checker, name string, check healthz.Checker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("unable to add check %s: server already started", name)
+	}
+	if _, ok := checks[name]; ok {
+		return fmt.Errorf("check %s already exists", name)
+	}
+	checks[name] = check
+	return nil
+}
+
+// Handle registers an additional handler for the given path.
+func (s *Server) Handle(path string, handler http.Handler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("unable to add handler for %s: server already started", path)
+	}
+	switch path {
+	case metricsPath, healthzPath, readyzPath:
+		return fmt.Errorf("path %s is reserved", path)
+	}
+	if _, ok := s.handlers[path]; ok {
+		return fmt.Errorf("handler for %s already exists", path)
+	}
+	s.handlers[path] = handler
+	return nil
+}
+
+// Start starts the server. Start blocks until the context is closed or the server fails.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return errors.New("server was started more than once")
+	}
+	s.started = true
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(s.opts.Gatherer, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	mux.Handle(healthzPath, http.StripPrefix(healthzPath, &healthz.Handler{Checks: s.healthz}))
+	mux.Handle(healthzPath+"/", http.StripPrefix(healthzPath, &healthz.Handler{Checks: s.healthz}))
+	mux.Handle(readyzPath, http.StripPrefix(readyzPath, &healthz.Handler{Checks: s.readyz}))
+	mux.Handle(readyzPath+"/", http.StripPrefix(readyzPath, &healthz.Handler{Checks: s.readyz}))
+	for path, h := range s.handlers {
+		mux.Handle(path, h)
+	}
+	s.mu.Unlock()
+
+	ln, err := net.Listen("tcp", s.opts.BindAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.opts.BindAddress, err)
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("Starting server", "address", ln.Addr().String())
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+		close(errs)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	log.Info("Shutting down server")
+	sctx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// Hasher hashes the keys to assign them to the members.
+type Hasher[K comparable] interface {
+	Hash(key K) uint64
+}
+
+// HasherFunc is a function that implements Hasher.
+type HasherFunc[K comparable] func(key K) uint64
+
+// Hash implements Hasher.
+func (fn HasherFunc[K]) Hash(key K) uint64 {
+	return fn(key)
+}
+
+// DefaultHasher hashes the fmt "%v" representation of the keys with FNV-1a.
+type DefaultHasher[K comparable] struct{}
+
+// Hash implements Hasher.
+func (DefaultHasher[K]) Hash(key K) uint64 {
+	return hashString(fmt.Sprintf("%v", key))
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix(h.Sum64())
+}
+
+// mix is the murmur3 64 bits finalizer, FNV alone does not spread
+// short and similar strings well enough over the ring.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// ring is an immutable consistent hashing ring.
+type ring struct {
+	members []string
+	points  []uint64
+	owners  map[uint64]string
+	// view identifies the members set.
+	view uint64
+}
+
+func newRing(members []string, vnodes int) *ring {
+	members = slices.Clone(members)
+	slices.Sort(members)
+	r := &ring{
+		members: members,
+		owners:  make(map[uint64]string, len(members)*vnodes),
+	}
+	h := fnv.New64a()
+	for _, m := range members {
+		h.Write([]byte(m))
+		h.Write([]byte{0})
+		for i := 0; i < vnodes; i++ {
+			p := hashString(m + "#" + strconv.Itoa(i))
+			// in the very unlikely case of a collision, keep the smallest
+			// member name so that all the members agree on the owner.
+			if o, ok := r.owners[p]; ok && o < m {
+				continue
+			}
+			r.owners[p] = m
+		}
+	}
+	r.view = h.Sum64()
+	for p := range r.owners {
+		r.points = append(r.points, p)
+	}
+	slices.Sort(r.points)
+	return r
+}
+
+// owner returns the member owning the hash, or an empty string if the ring is empty.
+func (r *ring) owner(h uint64) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	i, _ := slices.BinarySearch(r.points, h)
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
@@ -0,0 +1,420 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sharding distributes the keys of a controller across its replicas.
+//
+// Each replica registers itself as a member of a group in protodb and renews its registration
+// periodically. The keys are assigned to the live members using consistent hashing, and a replica
+// only enqueues and reconciles the keys it owns.
+//
+// When members join or leave, the keys are handed off without ever being owned by two members:
+// while the new assignment is being adopted, a member only owns the keys it owns in both the
+// previous and the new assignment. Once it finished reconciling the keys it loses, it acknowledges
+// the new assignment, and the new assignment takes effect when all the members acknowledged it.
+// The members then resync their sources to enqueue the keys they gained.
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"go.linka.cloud/protofilters/filters"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/pb"
+	"go.linka.cloud/protodb-controller/pkg/reconcile"
+)
+
+// Opts contains the options for a Sharder.
+type Opts[K comparable] struct {
+	// Name is the unique name of the member within the group.
+	// Defaults to the hostname followed by a random suffix.
+	Name string
+	// Hasher hashes the keys to assign them to the members. Defaults to DefaultHasher.
+	Hasher Hasher[K]
+	// VirtualNodes is the number of points of each member on the hash ring. Defaults to 128.
+	VirtualNodes int
+	// LeaseDuration is the duration after which a member which did not renew its registration
+	// is considered gone. Defaults to 15 seconds.
+	LeaseDuration time.Duration
+	// RenewInterval is the interval at which the member renews its registration.
+	// Defaults to a third of LeaseDuration.
+	RenewInterval time.Duration
+	Log           logr.Logger
+}
+
+// Opt allows to configure a Sharder.
+type Opt[K comparable] func(*Opts[K])
+
+// Sharder assigns the keys of a group of replicas to its members.
+type Sharder[K comparable] struct {
+	db     typed.Store[pb.Member, *pb.Member]
+	group  string
+	name   string
+	hasher Hasher[K]
+	vnodes int
+	lease  time.Duration
+	renew  time.Duration
+	log    logr.Logger
+
+	// mu has to be acquired for any access to current, target, view, inflight and listeners.
+	mu sync.RWMutex
+	// current is the assignment in effect.
+	current *ring
+	// target is the assignment being adopted, it is nil if there is none.
+	target *ring
+	// view is the view published by this member.
+	view uint64
+	// inflight counts the keys currently being reconciled.
+	inflight  map[K]int
+	listeners []func()
+
+	started atomic.Bool
+
+	// Configurable for testing
+	now func() time.Time
+}
+
+// New returns a new Sharder for the group, usually named after the controller.
+func New[K comparable](db protodb.Client, group string, o ...Opt[K]) *Sharder[K] {
+	opts := &Opts[K]{}
+	for _, f := range o {
+		f(opts)
+	}
+	if opts.Name == "" {
+		host, _ := os.Hostname()
+		opts.Name = fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+	}
+	if opts.Hasher == nil {
+		opts.Hasher = DefaultHasher[K]{}
+	}
+	if opts.VirtualNodes <= 0 {
+		opts.VirtualNodes = 128
+	}
+	if opts.LeaseDuration <= 0 {
+		opts.LeaseDuration = 15 * time.Second
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.LeaseDuration / 3
+	}
+	return &Sharder[K]{
+		db:       typed.NewStore[pb.Member](db),
+		group:    group,
+		name:     opts.Name,
+		hasher:   opts.Hasher,
+		vnodes:   opts.VirtualNodes,
+		lease:    opts.LeaseDuration,
+		renew:    opts.RenewInterval,
+		log:      opts.Log.WithValues("group", group, "member", opts.Name),
+		current:  newRing(nil, 0),
+		inflight: make(map[K]int),
+		now:      time.Now,
+	}
+}
+
+// Name returns the name of this member.
+func (s *Sharder[K]) Name() string {
+	return s.name
+}
+
+// Members returns the members of the assignment in effect.
+func (s *Sharder[K]) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.members
+}
+
+// OnChange registers a function called each time a new assignment takes effect.
+func (s *Sharder[K]) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Owns returns whether this member owns the key.
+func (s *Sharder[K]) Owns(key K) bool {
+	h := s.hasher.Hash(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.owns(h)
+}
+
+// owns must be called with the lock held.
+func (s *Sharder[K]) owns(h uint64) bool {
+	if s.current.owner(h) != s.name {
+		return false
+	}
+	return s.target == nil || s.target.owner(h) == s.name
+}
+
+// Start registers the member and keeps its registration and the assignment up to date
+// in the background until the context is done. The member then leaves the group.
+func (s *Sharder[K]) Start(ctx context.Context) error {
+	if !s.started.CompareAndSwap(false, true) {
+		return errors.New("sharder was started more than once")
+	}
+	if err := s.db.Raw().Register(ctx, pb.File_pb_sharding_proto); err != nil {
+		return err
+	}
+	ch, err := s.watch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.heartbeat(ctx); err != nil {
+		return err
+	}
+	if err := s.refresh(ctx); err != nil {
+		return err
+	}
+	go s.run(ctx, ch)
+	return nil
+}
+
+func (s *Sharder[K]) watch(ctx context.Context) (<-chan typed.Event[pb.Member, *pb.Member], error) {
+	return s.db.Watch(ctx, &pb.Member{}, protodb.WithFilter(filters.Where("group").StringEquals(s.group)))
+}
+
+func (s *Sharder[K]) run(ctx context.Context, ch <-chan typed.Event[pb.Member, *pb.Member]) {
+	defer s.leave()
+	t := time.NewTicker(s.renew)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.heartbeat(ctx); err != nil {
+				s.log.Error(err, "Failed to renew membership")
+			}
+		case _, ok := <-ch:
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				var err error
+				if ch, err = s.watch(ctx); err != nil {
+					// keep going with the periodic refresh only
+					s.log.Error(err, "Failed to watch members")
+					ch = nil
+				}
+			}
+		}
+		if err := s.refresh(ctx); err != nil {
+			s.log.Error(err, "Failed to refresh members")
+		}
+	}
+}
+
+func (s *Sharder[K]) heartbeat(ctx context.Context) error {
+	s.mu.RLock()
+	view := s.view
+	s.mu.RUnlock()
+	_, err := s.db.Set(ctx, &pb.Member{
+		ID:        s.id(),
+		Group:     s.group,
+		Name:      s.name,
+		ExpiresAt: timestamppb.New(s.now().Add(s.lease)),
+		View:      view,
+	})
+	return err
+}
+
+func (s *Sharder[K]) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.renew)
+	defer cancel()
+	if err := s.db.Delete(ctx, &pb.Member{ID: s.id()}); err != nil {
+		s.log.Error(err, "Failed to leave group")
+	}
+}
+
+// refresh reads the members, starts adopting a new assignment if the live members changed,
+// and puts the adopted assignment in effect once all the members acknowledged it.
+func (s *Sharder[K]) refresh(ctx context.Context) error {
+	rs, _, err := s.db.Get(ctx, &pb.Member{}, protodb.WithFilter(filters.Where("group").StringEquals(s.group)))
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	var alive []string
+	views := make(map[string]uint64, len(rs))
+	for _, v := range rs {
+		if !v.ExpiresAt.AsTime().After(now) {
+			continue
+		}
+		alive = append(alive, v.Name)
+		views[v.Name] = v.View
+	}
+	r := newRing(alive, s.vnodes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latest := s.current
+	if s.target != nil {
+		latest = s.target
+	}
+	if r.view != latest.view {
+		s.log.Info("Members changed, adopting new assignment", "members", r.members)
+		s.target = r
+		go s.adopt(ctx, r)
+	}
+	if s.target == nil {
+		return nil
+	}
+	for _, m := range s.target.members {
+		if views[m] != s.target.view {
+			return nil
+		}
+	}
+	s.log.Info("New assignment in effect", "members", s.target.members)
+	s.current, s.target = s.target, nil
+	for _, fn := range s.listeners {
+		go fn()
+	}
+	return nil
+}
+
+// adopt waits for the keys not owned in the assignment to be reconciled,
+// then acknowledges the assignment.
+func (s *Sharder[K]) adopt(ctx context.Context, r *ring) {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for {
+		s.mu.Lock()
+		if s.target != r {
+			// superseded by a newer assignment
+			s.mu.Unlock()
+			return
+		}
+		drained := true
+		for k := range s.inflight {
+			if r.owner(s.hasher.Hash(k)) != s.name {
+				drained = false
+				break
+			}
+		}
+		if drained {
+			s.view = r.view
+		}
+		s.mu.Unlock()
+		if drained {
+			if err := s.heartbeat(ctx); err != nil {
+				s.log.Error(err, "Failed to acknowledge assignment")
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Sharder[K]) acquire(key K) bool {
+	h := s.hasher.Hash(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.owns(h) {
+		return false
+	}
+	s.inflight[key]++
+	return true
+}
+
+func (s *Sharder[K]) release(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight[key]--; s.inflight[key] <= 0 {
+		delete(s.inflight, key)
+	}
+}
+
+func (s *Sharder[K]) id() string {
+	return s.group + "/" + s.name
+}
+
+// Reconciler wraps the reconciler so that it skips the keys not owned by this member,
+// and keeps track of the keys being reconciled for the hand-off.
+func (s *Sharder[K]) Reconciler(r reconcile.TypedReconciler[K]) reconcile.TypedReconciler[K] {
+	return reconcile.TypedFunc[K](func(ctx context.Context, key K) (reconcile.Result, error) {
+		if !s.acquire(key) {
+			return reconcile.Result{}, nil
+		}
+		defer s.release(key)
+		return r.Reconcile(ctx, key)
+	})
+}
+
+// Queue wraps the queue so that it drops the keys not owned by this member.
+func (s *Sharder[K]) Queue(q priorityqueue.PriorityQueue[K]) priorityqueue.PriorityQueue[K] {
+	return &queue[K]{PriorityQueue: q, s: s}
+}
+
+type queue[K comparable] struct {
+	priorityqueue.PriorityQueue[K]
+	s *Sharder[K]
+}
+
+func (q *queue[K]) AddWithOpts(o priorityqueue.AddOpts, items ...K) {
+	if owned := q.owned(items); len(owned) > 0 {
+		q.PriorityQueue.AddWithOpts(o, owned...)
+	}
+}
+
+func (q *queue[K]) AddWithContext(ctx context.Context, o priorityqueue.AddOpts, items ...K) {
+	owned := q.owned(items)
+	if len(owned) == 0 {
+		return
+	}
+	if ca, ok := q.PriorityQueue.(priorityqueue.ContextAdder[K]); ok {
+		ca.AddWithContext(ctx, o, owned...)
+	} else {
+		q.PriorityQueue.AddWithOpts(o, owned...)
+	}
+}
+
+// owned returns the items owned by this member, forgetting the others.
+func (q *queue[K]) owned(items []K) []K {
+	owned := make([]K, 0, len(items))
+	for _, v := range items {
+		if q.s.Owns(v) {
+			owned = append(owned, v)
+		} else {
+			q.PriorityQueue.Forget(v)
+		}
+	}
+	return owned
+}
+
+func (q *queue[K]) Add(item K) {
+	q.AddWithOpts(priorityqueue.AddOpts{}, item)
+}
+
+func (q *queue[K]) AddAfter(item K, after time.Duration) {
+	q.AddWithOpts(priorityqueue.AddOpts{After: after}, item)
+}
+
+func (q *queue[K]) AddRateLimited(item K) {
+	q.AddWithOpts(priorityqueue.AddOpts{RateLimited: true}, item)
+}
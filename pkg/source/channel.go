@@ -0,0 +1,80 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// TypedGenericEvent is an event originating outside of protodb, e.g. a webhook callback
+// or the result of polling an external system.
+type TypedGenericEvent[object any] struct {
+	// Object is the object the event is about.
+	Object object
+	// Priority is the priority the requests of the event are enqueued with.
+	Priority int
+}
+
+// TypedMapFunc returns the requests to reconcile for an object.
+type TypedMapFunc[object any, request comparable] func(ctx context.Context, obj object) []request
+
+// Channel returns a source enqueueing the requests the events read from ch are mapped to by fn.
+// The source stops when ch is closed or when the controller stops.
+// It can only be started once.
+func Channel[object any, request comparable](ch <-chan TypedGenericEvent[object], fn TypedMapFunc[object, request]) TypedSource[request] {
+	return &channel[object, request]{ch: ch, fn: fn}
+}
+
+type channel[object any, request comparable] struct {
+	ch      <-chan TypedGenericEvent[object]
+	fn      TypedMapFunc[object, request]
+	started atomic.Bool
+}
+
+func (c *channel[object, request]) String() string {
+	return fmt.Sprintf("channel source: %p", c.ch)
+}
+
+// Start implements TypedSource.
+func (c *channel[object, request]) Start(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+	if c.ch == nil {
+		return errors.New("channel is required")
+	}
+	if c.fn == nil {
+		return errors.New("map function is required")
+	}
+	if !c.started.CompareAndSwap(false, true) {
+		return errors.New("channel source can only be started once")
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-c.ch:
+				if !ok {
+					return
+				}
+				add(ctx, q, e.Priority, c.fn(ctx, e.Object)...)
+			}
+		}
+	}()
+	return nil
+}
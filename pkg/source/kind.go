@@ -0,0 +1,156 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"k8s.io/client-go/util/workqueue"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+)
+
+// KindOpts contains the options of a Kind source.
+type KindOpts[T any, PT typed.Message[T]] struct {
+	// Priority returns the priority the requests of an object are enqueued with.
+	// Defaults to 0 for all the objects.
+	Priority func(obj PT) int
+	// GetOptions are the options used to list and watch the objects, e.g. a filter.
+	GetOptions []protodb.GetOption
+}
+
+// KindOpt allows to configure a Kind source.
+type KindOpt[T any, PT typed.Message[T]] func(*KindOpts[T, PT])
+
+// WithKindPriority sets the function returning the priority the requests of an object are enqueued with.
+func WithKindPriority[T any, PT typed.Message[T]](fn func(obj PT) int) KindOpt[T, PT] {
+	return func(o *KindOpts[T, PT]) {
+		o.Priority = fn
+	}
+}
+
+// WithGetOptions sets the options used to list and watch the objects.
+func WithGetOptions[T any, PT typed.Message[T]](opts ...protodb.GetOption) KindOpt[T, PT] {
+	return func(o *KindOpts[T, PT]) {
+		o.GetOptions = opts
+	}
+}
+
+// Kind returns a source watching the protodb objects of type T and enqueueing the requests they are
+// mapped to by fn, e.g. to reconcile the owner of an object when it changes.
+// The objects are listed when the source starts: it is synced once their requests are enqueued.
+// Both the old and the new versions of the updated objects are mapped.
+func Kind[T any, PT typed.Message[T], request comparable](db protodb.Client, fn TypedMapFunc[PT, request], o ...KindOpt[T, PT]) TypedSyncingSource[request] {
+	opts := KindOpts[T, PT]{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Priority == nil {
+		opts.Priority = func(PT) int { return 0 }
+	}
+	k := &kind[T, PT, request]{fn: fn, opts: opts, synced: make(chan struct{})}
+	if db != nil {
+		k.db = typed.NewStore[T, PT](db)
+	}
+	return k
+}
+
+type kind[T any, PT typed.Message[T], request comparable] struct {
+	db      typed.Store[T, PT]
+	fn      TypedMapFunc[PT, request]
+	opts    KindOpts[T, PT]
+	started atomic.Bool
+	// synced is closed once the initial list was enqueued,
+	// or failed in which case syncErr is set.
+	synced  chan struct{}
+	syncErr error
+}
+
+func (k *kind[T, PT, request]) String() string {
+	var z PT
+	return fmt.Sprintf("kind source: protodb/%s", z.ProtoReflect().Descriptor().FullName())
+}
+
+// Start implements TypedSource.
+func (k *kind[T, PT, request]) Start(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+	if k.db == nil {
+		return errors.New("db is required")
+	}
+	if k.fn == nil {
+		return errors.New("map function is required")
+	}
+	if !k.started.CompareAndSwap(false, true) {
+		return errors.New("kind source can only be started once")
+	}
+	var z T
+	ch, err := k.db.Watch(ctx, &z, k.opts.GetOptions...)
+	if err != nil {
+		return err
+	}
+	go func() {
+		rs, _, err := k.db.Get(ctx, &z, k.opts.GetOptions...)
+		if err != nil {
+			k.syncErr = err
+			close(k.synced)
+			return
+		}
+		now := time.Now()
+		for _, v := range rs {
+			k.add(ctx, q, v, now)
+		}
+		close(k.synced)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-ch:
+				if !ok {
+					return
+				}
+				if e == nil || e.Err() != nil {
+					continue
+				}
+				// the map function may be slow, record when the event was received
+				now := time.Now()
+				k.add(ctx, q, e.Old(), now)
+				k.add(ctx, q, e.New(), now)
+			}
+		}
+	}()
+	return nil
+}
+
+// WaitForSync implements TypedSyncingSource.
+func (k *kind[T, PT, request]) WaitForSync(ctx context.Context) error {
+	select {
+	case <-k.synced:
+		return k.syncErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (k *kind[T, PT, request]) add(ctx context.Context, q workqueue.TypedRateLimitingInterface[request], obj PT, at time.Time) {
+	if obj == nil {
+		return
+	}
+	addWithOpts(ctx, q, priorityqueue.AddOpts{Priority: k.opts.Priority(obj), EventTime: at}, k.fn(ctx, obj)...)
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+)
+
+// add enqueues the requests with the priority if the queue supports it,
+// passing the context to the queues implementing priorityqueue.ContextAdder.
+//
+// Only the watch based sources, i.e. Kind, set the time of the event: the other sources
+// enqueue the requests as soon as they are triggered, the time of the add is used.
+// The event times are only recorded by the queues implementing priorityqueue.ContextAdder, i.e. the controllers' queues.
+func add[request comparable](ctx context.Context, q workqueue.TypedRateLimitingInterface[request], priority int, reqs ...request) {
+	addWithOpts(ctx, q, priorityqueue.AddOpts{Priority: priority}, reqs...)
+}
+
+func addWithOpts[request comparable](ctx context.Context, q workqueue.TypedRateLimitingInterface[request], o priorityqueue.AddOpts, reqs ...request) {
+	if len(reqs) == 0 {
+		return
+	}
+	if ca, ok := q.(priorityqueue.ContextAdder[request]); ok {
+		ca.AddWithContext(ctx, o, reqs...)
+		return
+	}
+	if pq, ok := q.(priorityqueue.PriorityQueue[request]); ok {
+		pq.AddWithOpts(o, reqs...)
+		return
+	}
+	for _, v := range reqs {
+		if o.After > 0 {
+			q.AddAfter(v, o.After)
+		} else {
+			q.Add(v)
+		}
+	}
+}
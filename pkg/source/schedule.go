@@ -0,0 +1,354 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	logf "go.linka.cloud/protodb-controller/pkg/internal/log"
+	"go.linka.cloud/protodb-controller/pkg/schedule"
+)
+
+var scheduleLog = logf.RuntimeLog.WithName("source").WithName("schedule")
+
+// MissedSchedulePolicy defines what a schedule source does with the activations
+// missed while the controller was not running.
+type MissedSchedulePolicy int
+
+const (
+	// SkipMissed ignores the missed activations: the keys are enqueued at their next activation.
+	SkipMissed MissedSchedulePolicy = iota
+	// RunMissed enqueues once, when the source starts, the keys which missed one or more activations.
+	// It requires ScheduleOpts.LastRun.
+	RunMissed
+)
+
+// ScheduleOpts contains the options of the schedule sources.
+type ScheduleOpts[request comparable] struct {
+	// Jitter is the maximum random delay added to each activation of each key, spreading
+	// the reconciles of the keys sharing the same schedule. Defaults to 0.
+	Jitter time.Duration
+	// Priority is the priority the keys are enqueued with.
+	Priority int
+	// Missed is the policy applied to the activations missed while the controller was not running.
+	// Defaults to SkipMissed.
+	Missed MissedSchedulePolicy
+	// LastRun returns the time of the last scheduled reconcile of the key, e.g. stored by the
+	// reconciler in the object, used to detect the missed activations. A zero time means that the
+	// key was never reconciled, in which case no activation is considered missed.
+	LastRun func(ctx context.Context, key request) time.Time
+	// StartingDeadline is the maximum delay after a missed activation for it to be run
+	// with RunMissed: older activations are skipped. Zero means no deadline.
+	StartingDeadline time.Duration
+	// Clock is used to schedule the activations. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// ScheduleOpt allows to configure a schedule source.
+type ScheduleOpt[request comparable] func(*ScheduleOpts[request])
+
+// WithJitter sets the maximum random delay added to each activation.
+func WithJitter[request comparable](d time.Duration) ScheduleOpt[request] {
+	return func(o *ScheduleOpts[request]) {
+		o.Jitter = d
+	}
+}
+
+// WithSchedulePriority sets the priority the keys are enqueued with.
+func WithSchedulePriority[request comparable](priority int) ScheduleOpt[request] {
+	return func(o *ScheduleOpts[request]) {
+		o.Priority = priority
+	}
+}
+
+// WithRunMissed enqueues when the source starts the keys which missed an activation since
+// their last run, as returned by lastRun, and at most deadline ago if deadline is not zero.
+func WithRunMissed[request comparable](lastRun func(ctx context.Context, key request) time.Time, deadline time.Duration) ScheduleOpt[request] {
+	return func(o *ScheduleOpts[request]) {
+		o.Missed = RunMissed
+		o.LastRun = lastRun
+		o.StartingDeadline = deadline
+	}
+}
+
+// WithScheduleClock sets the clock used to schedule the activations.
+func WithScheduleClock[request comparable](c clock.Clock) ScheduleOpt[request] {
+	return func(o *ScheduleOpts[request]) {
+		o.Clock = c
+	}
+}
+
+// Cron returns a source enqueueing the keys on the cron schedule spec, see the schedule package
+// for the syntax. The source fails to start if spec is invalid.
+func Cron[request comparable](spec string, keys []request, o ...ScheduleOpt[request]) TypedSource[request] {
+	sched, err := schedule.Parse(spec)
+	s := newScheduler(o...)
+	return TypedFunc[request](func(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if !s.started.CompareAndSwap(false, true) {
+			return errors.New("cron source can only be started once")
+		}
+		for _, v := range keys {
+			s.set(ctx, v, spec, sched)
+		}
+		go s.run(ctx, q)
+		return nil
+	})
+}
+
+// CronKind returns a source enqueueing the keys of the protodb objects of type T on the cron schedule
+// returned by spec for each object, e.g. stored in the object itself, or the same for all the objects.
+// The objects with an empty or invalid schedule are not scheduled.
+// The source is synced once the objects are listed.
+func CronKind[T any, PT typed.Message[T], request comparable](db protodb.Client, key func(PT) request, spec func(PT) string, o ...ScheduleOpt[request]) TypedSyncingSource[request] {
+	k := &cronKind[T, PT, request]{
+		key:    key,
+		spec:   spec,
+		s:      newScheduler(o...),
+		synced: make(chan struct{}),
+	}
+	if db != nil {
+		k.db = typed.NewStore[T, PT](db)
+	}
+	return k
+}
+
+type cronKind[T any, PT typed.Message[T], request comparable] struct {
+	db   typed.Store[T, PT]
+	key  func(PT) request
+	spec func(PT) string
+	s    *scheduler[request]
+	// synced is closed once the objects were listed,
+	// or failed in which case syncErr is set.
+	synced  chan struct{}
+	syncErr error
+}
+
+func (k *cronKind[T, PT, request]) String() string {
+	var z PT
+	return fmt.Sprintf("cron source: protodb/%s", z.ProtoReflect().Descriptor().FullName())
+}
+
+// Start implements TypedSource.
+func (k *cronKind[T, PT, request]) Start(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+	if k.db == nil {
+		return errors.New("db is required")
+	}
+	if k.key == nil || k.spec == nil {
+		return errors.New("key and spec functions are required")
+	}
+	if !k.s.started.CompareAndSwap(false, true) {
+		return errors.New("cron source can only be started once")
+	}
+	var z T
+	ch, err := k.db.Watch(ctx, &z)
+	if err != nil {
+		return err
+	}
+	go func() {
+		rs, _, err := k.db.Get(ctx, &z)
+		if err != nil {
+			k.syncErr = err
+			close(k.synced)
+			return
+		}
+		for _, v := range rs {
+			k.set(ctx, v)
+		}
+		close(k.synced)
+		go k.s.run(ctx, q)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-ch:
+				if !ok {
+					return
+				}
+				if e == nil || e.Err() != nil {
+					continue
+				}
+				switch e.Type() {
+				case protodb.EventTypeEnter, protodb.EventTypeUpdate:
+					k.set(ctx, e.New())
+				case protodb.EventTypeLeave:
+					k.s.remove(k.key(e.Old()))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// WaitForSync implements TypedSyncingSource.
+func (k *cronKind[T, PT, request]) WaitForSync(ctx context.Context) error {
+	select {
+	case <-k.synced:
+		return k.syncErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (k *cronKind[T, PT, request]) set(ctx context.Context, obj PT) {
+	key := k.key(obj)
+	spec := k.spec(obj)
+	if spec == "" {
+		k.s.remove(key)
+		return
+	}
+	sched, err := schedule.Parse(spec)
+	if err != nil {
+		scheduleLog.Error(err, "Invalid schedule", "key", key, "schedule", spec)
+		k.s.remove(key)
+		return
+	}
+	k.s.set(ctx, key, spec, sched)
+}
+
+type scheduleEntry struct {
+	spec  string
+	sched schedule.Schedule
+	next  time.Time
+}
+
+// scheduler enqueues the keys at their schedule's activations.
+type scheduler[request comparable] struct {
+	opts    ScheduleOpts[request]
+	started atomic.Bool
+
+	mu      sync.Mutex
+	entries map[request]*scheduleEntry
+	// wake interrupts the wait for the next activation when the entries change.
+	wake chan struct{}
+}
+
+func newScheduler[request comparable](o ...ScheduleOpt[request]) *scheduler[request] {
+	opts := ScheduleOpts[request]{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.RealClock{}
+	}
+	return &scheduler[request]{
+		opts:    opts,
+		entries: make(map[request]*scheduleEntry),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// set adds or updates the schedule of the key. The next activation of a new key is the first one
+// missed since its last run when RunMissed is enabled, otherwise the next one.
+func (s *scheduler[request]) set(ctx context.Context, key request, spec string, sched schedule.Schedule) {
+	now := s.opts.Clock.Now()
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if ok && e.spec == spec {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	next := sched.Next(now)
+	if !ok && s.opts.Missed == RunMissed && s.opts.LastRun != nil {
+		if last := s.opts.LastRun(ctx, key); !last.IsZero() {
+			if missed := sched.Next(last); !missed.IsZero() && !missed.After(now) &&
+				(s.opts.StartingDeadline <= 0 || now.Sub(missed) <= s.opts.StartingDeadline) {
+				next = missed
+			}
+		}
+	}
+	s.mu.Lock()
+	s.entries[key] = &scheduleEntry{spec: spec, sched: sched, next: next}
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *scheduler[request]) remove(key request) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *scheduler[request]) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *scheduler[request]) run(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) {
+	for {
+		now := s.opts.Clock.Now()
+		var due []request
+		var next time.Time
+		s.mu.Lock()
+		for k, e := range s.entries {
+			if e.next.IsZero() {
+				continue
+			}
+			if !e.next.After(now) {
+				due = append(due, k)
+				e.next = e.sched.Next(now)
+				if e.next.IsZero() {
+					continue
+				}
+			}
+			if next.IsZero() || e.next.Before(next) {
+				next = e.next
+			}
+		}
+		s.mu.Unlock()
+		for _, k := range due {
+			o := priorityqueue.AddOpts{Priority: s.opts.Priority}
+			if s.opts.Jitter > 0 {
+				o.After = rand.N(s.opts.Jitter)
+			}
+			addWithOpts(ctx, q, o, k)
+		}
+		var timer clock.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = s.opts.Clock.NewTimer(next.Sub(now))
+			fire = timer.C()
+		}
+		select {
+		case <-ctx.Done():
+		case <-fire:
+		case <-s.wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
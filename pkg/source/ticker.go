@@ -0,0 +1,115 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+)
+
+// TickerOpts contains the options of a Ticker source.
+type TickerOpts struct {
+	// Immediate enqueues the keys when the source starts, instead of waiting for the first tick.
+	Immediate bool
+	// Priority is the priority the keys are enqueued with.
+	Priority int
+	// Clock is used to create the ticker. Defaults to the real clock.
+	Clock clock.WithTicker
+}
+
+// TickerOpt allows to configure a Ticker source.
+type TickerOpt func(*TickerOpts)
+
+// WithImmediate enqueues the keys when the source starts.
+func WithImmediate() TickerOpt {
+	return func(o *TickerOpts) {
+		o.Immediate = true
+	}
+}
+
+// WithTickerPriority sets the priority the keys are enqueued with.
+func WithTickerPriority(priority int) TickerOpt {
+	return func(o *TickerOpts) {
+		o.Priority = priority
+	}
+}
+
+// WithTickerClock sets the clock used to create the ticker.
+func WithTickerClock(c clock.WithTicker) TickerOpt {
+	return func(o *TickerOpts) {
+		o.Clock = c
+	}
+}
+
+// Ticker returns a source enqueueing the keys at each interval, e.g. to periodically
+// reconcile a singleton or an external system.
+func Ticker[request comparable](interval time.Duration, keys []request, o ...TickerOpt) TypedSource[request] {
+	keys = slices.Clone(keys)
+	return TickerFunc(interval, func(context.Context) []request { return keys }, o...)
+}
+
+// TickerFunc returns a source enqueueing the keys returned by fn at each interval.
+func TickerFunc[request comparable](interval time.Duration, fn func(ctx context.Context) []request, o ...TickerOpt) TypedSource[request] {
+	opts := TickerOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.RealClock{}
+	}
+	return &ticker[request]{interval: interval, fn: fn, opts: opts}
+}
+
+type ticker[request comparable] struct {
+	interval time.Duration
+	fn       func(ctx context.Context) []request
+	opts     TickerOpts
+}
+
+func (t *ticker[request]) String() string {
+	return fmt.Sprintf("ticker source: %s", t.interval)
+}
+
+// Start implements TypedSource.
+func (t *ticker[request]) Start(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+	if t.interval <= 0 {
+		return errors.New("ticker interval must be positive")
+	}
+	if t.fn == nil {
+		return errors.New("keys function is required")
+	}
+	tk := t.opts.Clock.NewTicker(t.interval)
+	go func() {
+		defer tk.Stop()
+		if t.opts.Immediate {
+			add(ctx, q, t.opts.Priority, t.fn(ctx)...)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tk.C():
+				add(ctx, q, t.opts.Priority, t.fn(ctx)...)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/util/workqueue"
+
+	ctrlmetrics "go.linka.cloud/protodb-controller/pkg/internal/controller/metrics"
+	"go.linka.cloud/protodb-controller/pkg/metrics"
+)
+
+const (
+	// DefaultSignatureHeader is the default header containing the HMAC signature of the webhook payloads.
+	DefaultSignatureHeader = "X-Signature-256"
+	// DefaultMaxBodySize is the default maximum size of the webhook payloads.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// WebhookMapFunc returns the requests to reconcile for a webhook call. An error rejects the call
+// with a 400 Bad Request status.
+type WebhookMapFunc[request comparable] func(r *http.Request, body []byte) ([]request, error)
+
+// WebhookOpts contains the options of a Webhook source.
+type WebhookOpts struct {
+	// Secret, if set, enables the validation of the payloads' HMAC-SHA256 signature, which
+	// is expected hex encoded in the SignatureHeader, optionally prefixed by "sha256=".
+	Secret []byte
+	// SignatureHeader is the header containing the signature. Defaults to DefaultSignatureHeader.
+	SignatureHeader string
+	// Validate, if set, is called to validate the calls after the signature, e.g. to check
+	// an event type header. An error rejects the call with a 400 Bad Request status.
+	Validate func(r *http.Request, body []byte) error
+	// MaxBodySize is the maximum size of the payloads. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+	// Priority is the priority the requests are enqueued with.
+	Priority int
+	// MetricsProvider creates the webhook's metrics. Defaults to the prometheus vectors
+	// registered in metrics.Registry. The providers returned by controller.NewPrometheusMetricsProvider
+	// implement it, allowing to report the webhook's metrics to the same registry as its controller.
+	MetricsProvider metrics.WebhookMetricsProvider
+}
+
+// WebhookOpt allows to configure a Webhook source.
+type WebhookOpt func(*WebhookOpts)
+
+// WithSecret enables the validation of the payloads' HMAC-SHA256 signature with the secret.
+func WithSecret(secret []byte) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.Secret = secret
+	}
+}
+
+// WithSignatureHeader sets the header containing the payloads' signature.
+func WithSignatureHeader(header string) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.SignatureHeader = header
+	}
+}
+
+// WithValidate sets the function validating the calls.
+func WithValidate(fn func(r *http.Request, body []byte) error) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.Validate = fn
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the payloads.
+func WithMaxBodySize(n int64) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.MaxBodySize = n
+	}
+}
+
+// WithWebhookMetricsProvider sets the provider creating the webhook's metrics.
+func WithWebhookMetricsProvider(p metrics.WebhookMetricsProvider) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.MetricsProvider = p
+	}
+}
+
+// WithWebhookPriority sets the priority the requests are enqueued with.
+func WithWebhookPriority(priority int) WebhookOpt {
+	return func(o *WebhookOpts) {
+		o.Priority = priority
+	}
+}
+
+// TypedWebhook is a source enqueueing the requests of the calls it receives as an http.Handler,
+// e.g. the notifications of a git forge or of a cloud provider.
+//
+// It must be added to the controller with Watch and served, e.g. with server.Server.Handle.
+// The calls received before the controller started are rejected with a 503 Service Unavailable status,
+// so that the caller retries them.
+type TypedWebhook[request comparable] struct {
+	name string
+	fn   WebhookMapFunc[request]
+	opts WebhookOpts
+
+	mu  sync.RWMutex
+	ctx context.Context
+	q   workqueue.TypedRateLimitingInterface[request]
+}
+
+// Webhook returns a webhook source mapping the calls to requests with fn.
+// The name identifies the webhook in the metrics.
+func Webhook[request comparable](name string, fn WebhookMapFunc[request], o ...WebhookOpt) *TypedWebhook[request] {
+	opts := WebhookOpts{}
+	for _, f := range o {
+		f(&opts)
+	}
+	if opts.SignatureHeader == "" {
+		opts.SignatureHeader = DefaultSignatureHeader
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MetricsProvider == nil {
+		opts.MetricsProvider = ctrlmetrics.DefaultProvider
+	}
+	return &TypedWebhook[request]{name: name, fn: fn, opts: opts}
+}
+
+func (w *TypedWebhook[request]) String() string {
+	return fmt.Sprintf("webhook source: %s", w.name)
+}
+
+// Start implements TypedSource.
+func (w *TypedWebhook[request]) Start(ctx context.Context, q workqueue.TypedRateLimitingInterface[request]) error {
+	if w.fn == nil {
+		return errors.New("map function is required")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.q != nil {
+		return errors.New("webhook source can only be started once")
+	}
+	w.ctx, w.q = ctx, q
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (w *TypedWebhook[request]) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.reject(rw, "method_not_allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.mu.RLock()
+	ctx, q := w.ctx, w.q
+	w.mu.RUnlock()
+	if q == nil || ctx.Err() != nil {
+		w.reject(rw, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, w.opts.MaxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.reject(rw, "too_large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.reject(rw, "read_error", http.StatusBadRequest)
+		return
+	}
+	if w.opts.Secret != nil && !w.verify(r.Header.Get(w.opts.SignatureHeader), body) {
+		w.reject(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if w.opts.Validate != nil {
+		if err := w.opts.Validate(r, body); err != nil {
+			w.reject(rw, "validation_failed", http.StatusBadRequest)
+			return
+		}
+	}
+	reqs, err := w.fn(r, body)
+	if err != nil {
+		w.reject(rw, "map_failed", http.StatusBadRequest)
+		return
+	}
+	add(r.Context(), q, w.opts.Priority, reqs...)
+	w.opts.MetricsProvider.NewWebhookRequestsMetric(w.name, "accepted", "").Inc()
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (w *TypedWebhook[request]) verify(signature string, body []byte) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, w.opts.Secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func (w *TypedWebhook[request]) reject(rw http.ResponseWriter, reason string, code int) {
+	w.opts.MetricsProvider.NewWebhookRequestsMetric(w.name, "rejected", reason).Inc()
+	http.Error(rw, http.StatusText(code), code)
+}
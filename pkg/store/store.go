@@ -0,0 +1,45 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package store provides the interface of the typed protodb stores the reconcilers depend on.
+//
+// Reconcilers depending on a Store instead of a typed.Store or a protodb.Client can be unit-tested
+// without protodb, using the fake store of the storetest package.
+package store
+
+import (
+	"context"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+
+	"go.linka.cloud/protodb-controller/pkg/pb"
+)
+
+// Store is the subset of typed.Store used to read, write and watch objects of type T.
+// It is implemented by the stores returned by typed.NewStore.
+type Store[T any, PT typed.Message[T]] interface {
+	Get(ctx context.Context, m PT, opts ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error)
+	Set(ctx context.Context, m PT, opts ...protodb.SetOption) (PT, error)
+	Delete(ctx context.Context, m PT) error
+	Watch(ctx context.Context, m PT, opts ...protodb.GetOption) (<-chan typed.Event[T, PT], error)
+	Tx(ctx context.Context, opts ...protodb.TxOption) (typed.Tx[T, PT], error)
+}
+
+var _ Store[pb.Lease, *pb.Lease] = typed.Store[pb.Lease, *pb.Lease](nil)
+
+// New returns the Store of the objects of type T stored in db.
+func New[T any, PT typed.Message[T]](db protodb.Client) Store[T, PT] {
+	return typed.NewStore[T, PT](db)
+}
@@ -0,0 +1,350 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storetest provides an in-memory fake of the typed protodb stores, allowing to
+// unit-test reconcilers without protodb.
+//
+// The fake records the calls it receives, can be configured to fail them, and simulates the
+// conflicts of the optimistic transactions of protodb: a transaction fails to commit if an object
+// it read was written since, or if a conflict was injected for one of the objects it wrote.
+//
+// The get and watch options, e.g. filters, paging or field masks, are not supported and are ignored:
+// a message with an empty key matches all the objects.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+	"google.golang.org/protobuf/proto"
+
+	"go.linka.cloud/protodb-controller/pkg/pb"
+	"go.linka.cloud/protodb-controller/pkg/store"
+)
+
+// ErrConflict is returned when a write or a transaction conflicts with another write.
+var ErrConflict = errors.New("storetest: conflict")
+
+var (
+	_ store.Store[pb.Lease, *pb.Lease] = (*Store[pb.Lease, *pb.Lease])(nil)
+	_ typed.Store[pb.Lease, *pb.Lease] = (*Store[pb.Lease, *pb.Lease])(nil)
+)
+
+// Method is a method of the store.
+type Method string
+
+const (
+	MethodGet    Method = "Get"
+	MethodSet    Method = "Set"
+	MethodDelete Method = "Delete"
+	MethodWatch  Method = "Watch"
+	MethodTx     Method = "Tx"
+	MethodCommit Method = "Commit"
+)
+
+// Call is a call received by the store.
+type Call[T any, PT typed.Message[T]] struct {
+	Method Method
+	// Object is a copy of the message passed to the method, it is nil for Tx and Commit.
+	Object PT
+	// Tx is true if the call was made in a transaction.
+	Tx bool
+	// Err is the error returned by the call.
+	Err error
+}
+
+// Store is an in-memory fake of typed.Store. Its zero value is not usable, use New.
+type Store[T any, PT typed.Message[T]] struct {
+	key func(PT) string
+
+	mu       sync.Mutex
+	objects  map[string]PT
+	versions map[string]uint64
+	version  uint64
+	calls    []Call[T, PT]
+	// failures are the errors returned by the next calls of each method.
+	failures  map[Method][]error
+	reactors  []func(c Call[T, PT]) error
+	conflicts map[string]int
+	watchers  []*watcher[T, PT]
+}
+
+// New returns a store identifying the objects with the key function, and containing the given objects.
+func New[T any, PT typed.Message[T]](key func(PT) string, objs ...PT) *Store[T, PT] {
+	s := &Store[T, PT]{
+		key:       key,
+		objects:   make(map[string]PT),
+		versions:  make(map[string]uint64),
+		failures:  make(map[Method][]error),
+		conflicts: make(map[string]int),
+	}
+	for _, v := range objs {
+		s.version++
+		s.objects[key(v)] = clone(v)
+		s.versions[key(v)] = s.version
+	}
+	return s
+}
+
+// Get implements typed.Store.
+func (s *Store[T, PT]) Get(ctx context.Context, m PT, _ ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call(MethodGet, m, false); err != nil {
+		return nil, nil, err
+	}
+	return s.get(m, nil), &protodb.PagingInfo{}, nil
+}
+
+// Set implements typed.Store.
+func (s *Store[T, PT]) Set(ctx context.Context, m PT, _ ...protodb.SetOption) (PT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call(MethodSet, m, false); err != nil {
+		return nil, err
+	}
+	k := s.key(m)
+	if err := s.write(k); err != nil {
+		s.setErr(err)
+		return nil, err
+	}
+	s.set(k, m)
+	return clone(m), nil
+}
+
+// Delete implements typed.Store.
+func (s *Store[T, PT]) Delete(ctx context.Context, m PT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call(MethodDelete, m, false); err != nil {
+		return err
+	}
+	k := s.key(m)
+	if err := s.write(k); err != nil {
+		s.setErr(err)
+		return err
+	}
+	s.delete(k)
+	return nil
+}
+
+// Watch implements typed.Store. The events are sent until ctx is done.
+func (s *Store[T, PT]) Watch(ctx context.Context, m PT, _ ...protodb.GetOption) (<-chan typed.Event[T, PT], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call(MethodWatch, m, false); err != nil {
+		return nil, err
+	}
+	w := &watcher[T, PT]{
+		key:    s.key(m),
+		ch:     make(chan typed.Event[T, PT]),
+		signal: make(chan struct{}, 1),
+	}
+	s.watchers = append(s.watchers, w)
+	go func() {
+		w.run(ctx)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.watchers = slices.DeleteFunc(s.watchers, func(v *watcher[T, PT]) bool {
+			return v == w
+		})
+	}()
+	return w.ch, nil
+}
+
+// Tx implements typed.Store.
+func (s *Store[T, PT]) Tx(ctx context.Context, _ ...protodb.TxOption) (typed.Tx[T, PT], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call(MethodTx, nil, false); err != nil {
+		return nil, err
+	}
+	return &tx[T, PT]{s: s, reads: make(map[string]uint64), writes: make(map[string]PT)}, nil
+}
+
+// Raw implements typed.Store. The fake has no underlying client: it returns nil.
+func (s *Store[T, PT]) Raw() protodb.Client {
+	return nil
+}
+
+// Close implements typed.Store.
+func (s *Store[T, PT]) Close() error {
+	return nil
+}
+
+// Objects returns a copy of the objects stored, sorted by key.
+func (s *Store[T, PT]) Objects() []PT {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(nil, nil)
+}
+
+// Calls returns the calls received by the store.
+func (s *Store[T, PT]) Calls() []Call[T, PT] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+// CallsTo returns the calls of the method received by the store.
+func (s *Store[T, PT]) CallsTo(method Method) []Call[T, PT] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call[T, PT]
+	for _, v := range s.calls {
+		if v.Method == method {
+			calls = append(calls, v)
+		}
+	}
+	return calls
+}
+
+// ResetCalls forgets the calls received by the store.
+func (s *Store[T, PT]) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// FailNext makes the next call of the method, in or outside a transaction, return err.
+// Successive calls to FailNext make the successive calls of the method fail.
+func (s *Store[T, PT]) FailNext(method Method, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], err)
+}
+
+// React registers a function called before each call: if it returns an error, the call
+// fails with it without being executed. The reactors are called in the order they were
+// registered, and must not call the store.
+func (s *Store[T, PT]) React(fn func(c Call[T, PT]) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reactors = append(s.reactors, fn)
+}
+
+// Conflict makes the next n writes of the object with the given key fail with ErrConflict,
+// as if another client wrote it concurrently. The writes made in a transaction fail on Commit.
+func (s *Store[T, PT]) Conflict(key string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conflicts[key] += n
+}
+
+// call records the call and returns the error it must fail with, if any.
+func (s *Store[T, PT]) call(method Method, m PT, tx bool) error {
+	c := Call[T, PT]{Method: method, Tx: tx}
+	if m != nil {
+		c.Object = clone(m)
+	}
+	if errs := s.failures[method]; len(errs) != 0 {
+		c.Err, s.failures[method] = errs[0], errs[1:]
+	}
+	for _, fn := range s.reactors {
+		if c.Err != nil {
+			break
+		}
+		c.Err = fn(c)
+	}
+	s.calls = append(s.calls, c)
+	return c.Err
+}
+
+// setErr sets the error of the last recorded call.
+func (s *Store[T, PT]) setErr(err error) {
+	s.calls[len(s.calls)-1].Err = err
+}
+
+// write checks that the object with the key can be written.
+func (s *Store[T, PT]) write(k string) error {
+	if k == "" {
+		return errors.New("storetest: missing key")
+	}
+	if s.conflicts[k] > 0 {
+		s.conflicts[k]--
+		return fmt.Errorf("%w: %s", ErrConflict, k)
+	}
+	return nil
+}
+
+// get returns the objects matching m, sorted by key. When versions is not nil, it records
+// the versions of the objects read.
+func (s *Store[T, PT]) get(m PT, versions map[string]uint64) []PT {
+	k := ""
+	if m != nil {
+		k = s.key(m)
+	}
+	if k != "" {
+		if versions != nil {
+			versions[k] = s.versions[k]
+		}
+		if v, ok := s.objects[k]; ok {
+			return []PT{clone(v)}
+		}
+		return nil
+	}
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	out := make([]PT, 0, len(keys))
+	for _, k := range keys {
+		if versions != nil {
+			versions[k] = s.versions[k]
+		}
+		out = append(out, clone(s.objects[k]))
+	}
+	return out
+}
+
+func (s *Store[T, PT]) set(k string, m PT) {
+	old, ok := s.objects[k]
+	s.objects[k] = clone(m)
+	s.version++
+	s.versions[k] = s.version
+	if ok {
+		s.notify(k, event[T, PT]{typ: protodb.EventTypeUpdate, old: old, new: clone(m)})
+	} else {
+		s.notify(k, event[T, PT]{typ: protodb.EventTypeEnter, new: clone(m)})
+	}
+}
+
+func (s *Store[T, PT]) delete(k string) {
+	old, ok := s.objects[k]
+	if !ok {
+		return
+	}
+	delete(s.objects, k)
+	s.version++
+	s.versions[k] = s.version
+	s.notify(k, event[T, PT]{typ: protodb.EventTypeLeave, old: old})
+}
+
+func (s *Store[T, PT]) notify(k string, e event[T, PT]) {
+	for _, w := range s.watchers {
+		if w.key == "" || w.key == k {
+			w.push(e)
+		}
+	}
+}
+
+func clone[T any, PT typed.Message[T]](m PT) PT {
+	return proto.Clone(m).(PT)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+)
+
+var errTxClosed = errors.New("storetest: transaction closed")
+
+// tx buffers its writes until it is committed. It records the versions of the objects
+// it read to detect the conflicting writes on Commit.
+type tx[T any, PT typed.Message[T]] struct {
+	s      *Store[T, PT]
+	reads  map[string]uint64
+	writes map[string]PT // nil values are deletes
+	closed bool
+}
+
+func (t *tx[T, PT]) Get(ctx context.Context, m PT, _ ...protodb.GetOption) ([]PT, *protodb.PagingInfo, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if err := t.s.call(MethodGet, m, true); err != nil {
+		return nil, nil, err
+	}
+	if t.closed {
+		t.s.setErr(errTxClosed)
+		return nil, nil, errTxClosed
+	}
+	k := t.s.key(m)
+	if k != "" {
+		if v, ok := t.writes[k]; ok {
+			if v == nil {
+				return nil, &protodb.PagingInfo{}, nil
+			}
+			return []PT{clone(v)}, &protodb.PagingInfo{}, nil
+		}
+		return t.s.get(m, t.reads), &protodb.PagingInfo{}, nil
+	}
+	objs := make(map[string]PT)
+	for _, v := range t.s.get(m, t.reads) {
+		objs[t.s.key(v)] = v
+	}
+	for k, v := range t.writes {
+		if v == nil {
+			delete(objs, k)
+		} else {
+			objs[k] = clone(v)
+		}
+	}
+	out := make([]PT, 0, len(objs))
+	for _, k := range slices.Sorted(maps.Keys(objs)) {
+		out = append(out, objs[k])
+	}
+	return out, &protodb.PagingInfo{}, nil
+}
+
+func (t *tx[T, PT]) Set(ctx context.Context, m PT, _ ...protodb.SetOption) (PT, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if err := t.s.call(MethodSet, m, true); err != nil {
+		return nil, err
+	}
+	if err := t.check(t.s.key(m)); err != nil {
+		t.s.setErr(err)
+		return nil, err
+	}
+	t.writes[t.s.key(m)] = clone(m)
+	return clone(m), nil
+}
+
+func (t *tx[T, PT]) Delete(ctx context.Context, m PT) error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if err := t.s.call(MethodDelete, m, true); err != nil {
+		return err
+	}
+	if err := t.check(t.s.key(m)); err != nil {
+		t.s.setErr(err)
+		return err
+	}
+	t.writes[t.s.key(m)] = nil
+	return nil
+}
+
+// Commit applies the writes, or fails with ErrConflict if an object read by the transaction
+// was written since, or if a conflict was injected for one of the objects written.
+func (t *tx[T, PT]) Commit(ctx context.Context) error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if err := t.s.call(MethodCommit, nil, true); err != nil {
+		return err
+	}
+	if t.closed {
+		t.s.setErr(errTxClosed)
+		return errTxClosed
+	}
+	t.closed = true
+	for k, v := range t.reads {
+		if t.s.versions[k] != v {
+			err := fmt.Errorf("%w: %s", ErrConflict, k)
+			t.s.setErr(err)
+			return err
+		}
+	}
+	keys := slices.Sorted(maps.Keys(t.writes))
+	for _, k := range keys {
+		if err := t.s.write(k); err != nil {
+			t.s.setErr(err)
+			return err
+		}
+	}
+	for _, k := range keys {
+		if v := t.writes[k]; v != nil {
+			t.s.set(k, v)
+		} else {
+			t.s.delete(k)
+		}
+	}
+	return nil
+}
+
+func (t *tx[T, PT]) Close() {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	t.closed = true
+}
+
+func (t *tx[T, PT]) check(k string) error {
+	if t.closed {
+		return errTxClosed
+	}
+	if k == "" {
+		return errors.New("storetest: missing key")
+	}
+	return nil
+}
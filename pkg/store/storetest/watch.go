@@ -0,0 +1,89 @@
+// Copyright 2025 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storetest
+
+import (
+	"context"
+	"sync"
+
+	"go.linka.cloud/protodb"
+	"go.linka.cloud/protodb/typed"
+)
+
+type event[T any, PT typed.Message[T]] struct {
+	typ protodb.EventType
+	old PT
+	new PT
+}
+
+func (e event[T, PT]) Type() protodb.EventType {
+	return e.typ
+}
+
+func (e event[T, PT]) Old() PT {
+	return e.old
+}
+
+func (e event[T, PT]) New() PT {
+	return e.new
+}
+
+func (e event[T, PT]) Err() error {
+	return nil
+}
+
+// watcher buffers the events so that the writes never block on a slow watcher.
+type watcher[T any, PT typed.Message[T]] struct {
+	key    string
+	ch     chan typed.Event[T, PT]
+	signal chan struct{}
+
+	mu     sync.Mutex
+	events []typed.Event[T, PT]
+}
+
+func (w *watcher[T, PT]) push(e typed.Event[T, PT]) {
+	w.mu.Lock()
+	w.events = append(w.events, e)
+	w.mu.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher[T, PT]) run(ctx context.Context) {
+	defer close(w.ch)
+	for {
+		w.mu.Lock()
+		if len(w.events) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.signal:
+			}
+			continue
+		}
+		e := w.events[0]
+		w.events = w.events[1:]
+		w.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case w.ch <- e:
+		}
+	}
+}
@@ -17,24 +17,86 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.linka.cloud/protodb"
 	"go.linka.cloud/protodb/typed"
 	"k8s.io/client-go/util/workqueue"
+
+	"go.linka.cloud/protodb-controller/pkg/controller/priorityqueue"
+	"go.linka.cloud/protodb-controller/pkg/record"
 )
 
-func newSrc[T any, PT Message[T], K comparable](db typed.Store[T, PT], key func(PT) K) *src[T, PT, K] {
+// SourceOpts contains the options for the protodb source of a Controller.
+type SourceOpts[T any, PT Message[T]] struct {
+	// Priority returns the priority with which the keys are enqueued.
+	// Defaults to 0 for all events.
+	Priority PriorityFunc[T, PT]
+	// Context returns the context the key of the event is enqueued with, derived from the source's context.
+	// When tracing is enabled, the reconcile span is linked to the span of this context, e.g. a span context
+	// the writer stored in the object. Defaults to the source's context.
+	Context func(ctx context.Context, e Event[T, PT]) context.Context
+	// Recorder records the events of the source and the resulting reconciles, allowing to replay them.
+	Recorder *record.Recorder
+}
+
+// SourceOpt allows to configure the protodb source of a Controller.
+type SourceOpt[T any, PT Message[T]] func(*SourceOpts[T, PT])
+
+// WithPriorityFunc sets the function used to compute the priority of the enqueued keys.
+func WithPriorityFunc[T any, PT Message[T]](fn PriorityFunc[T, PT]) SourceOpt[T, PT] {
+	return func(o *SourceOpts[T, PT]) {
+		o.Priority = fn
+	}
+}
+
+// WithEventContext sets the function returning the context the keys are enqueued with.
+func WithEventContext[T any, PT Message[T]](fn func(ctx context.Context, e Event[T, PT]) context.Context) SourceOpt[T, PT] {
+	return func(o *SourceOpts[T, PT]) {
+		o.Context = fn
+	}
+}
+
+// WithRecorder sets the recorder of the source's events and of the resulting reconciles.
+func WithRecorder[T any, PT Message[T]](r *record.Recorder) SourceOpt[T, PT] {
+	return func(o *SourceOpts[T, PT]) {
+		o.Recorder = r
+	}
+}
+
+func newSrc[T any, PT Message[T], K comparable](db typed.Store[T, PT], key func(PT) K, o ...SourceOpt[T, PT]) *src[T, PT, K] {
+	opts := &SourceOpts[T, PT]{}
+	for _, f := range o {
+		f(opts)
+	}
+	if opts.Priority == nil {
+		opts.Priority = func(Event[T, PT]) int { return 0 }
+	}
+	if opts.Context == nil {
+		opts.Context = func(ctx context.Context, _ Event[T, PT]) context.Context { return ctx }
+	}
 	return &src[T, PT, K]{
-		db:   db,
-		key:  key,
-		sync: make(chan struct{}, 1),
+		db:       db,
+		key:      key,
+		priority: opts.Priority,
+		context:  opts.Context,
+		recorder: opts.Recorder,
+		sync:     make(chan struct{}, 1),
+		synced:   make(chan struct{}),
 	}
 }
 
 type src[T any, PT Message[T], K comparable] struct {
-	db   typed.Store[T, PT]
-	key  func(PT) K
-	sync chan struct{}
+	db       typed.Store[T, PT]
+	key      func(PT) K
+	priority PriorityFunc[T, PT]
+	context  func(ctx context.Context, e Event[T, PT]) context.Context
+	recorder *record.Recorder
+	sync     chan struct{}
+	// synced is closed once the initial list was enqueued,
+	// or failed in which case syncErr is set.
+	synced  chan struct{}
+	syncErr error
 }
 
 func (s *src[T, PT, K]) String() string {
@@ -42,8 +104,13 @@ func (s *src[T, PT, K]) String() string {
 	return fmt.Sprintf("protodb/%s", z.ProtoReflect().Descriptor().FullName())
 }
 
+// Sync requests a resync of the objects. It never blocks: a resync requested while another one
+// is pending, or before the source is started, is coalesced with it.
 func (s *src[T, PT, K]) Sync() {
-	s.sync <- struct{}{}
+	select {
+	case s.sync <- struct{}{}:
+	default:
+	}
 }
 
 func (s *src[T, PT, K]) Start(ctx context.Context, w workqueue.TypedRateLimitingInterface[K]) error {
@@ -58,19 +125,29 @@ func (s *src[T, PT, K]) Start(ctx context.Context, w workqueue.TypedRateLimiting
 	}
 	go func() {
 		defer w.ShutDown()
+		typ := EventTypeInitialList
 		for {
 			select {
 			case _, ok := <-s.sync:
 				if !ok {
 					return
 				}
+				now := time.Now()
 				rs, _, err := s.db.Get(ctx, &z)
 				if err != nil {
+					if typ == EventTypeInitialList {
+						s.syncErr = err
+						close(s.synced)
+					}
 					return
 				}
 				for _, v := range rs {
-					w.Add(s.key(v))
+					s.add(ctx, w, Event[T, PT]{Type: typ, New: v, Time: now})
+				}
+				if typ == EventTypeInitialList {
+					close(s.synced)
 				}
+				typ = EventTypeResync
 			case e, ok := <-ch:
 				if !ok {
 					return
@@ -81,13 +158,15 @@ func (s *src[T, PT, K]) Start(ctx context.Context, w workqueue.TypedRateLimiting
 				if e.Err() != nil {
 					continue
 				}
+				// the protodb events do not carry the time of the write
+				now := time.Now()
 				switch e.Type() {
 				case protodb.EventTypeEnter:
-					w.Add(s.key(e.New()))
+					s.add(ctx, w, Event[T, PT]{Type: EventTypeCreate, New: e.New(), Time: now})
 				case protodb.EventTypeUpdate:
-					w.Add(s.key(e.New()))
+					s.add(ctx, w, Event[T, PT]{Type: EventTypeUpdate, Old: e.Old(), New: e.New(), Time: now})
 				case protodb.EventTypeLeave:
-					w.Add(s.key(e.Old()))
+					s.add(ctx, w, Event[T, PT]{Type: EventTypeDelete, Old: e.Old(), Time: now})
 				}
 			case <-ctx.Done():
 				return
@@ -96,3 +175,30 @@ func (s *src[T, PT, K]) Start(ctx context.Context, w workqueue.TypedRateLimiting
 	}()
 	return nil
 }
+
+// WaitForSync implements source.TypedSyncingSource.
+func (s *src[T, PT, K]) WaitForSync(ctx context.Context) error {
+	select {
+	case <-s.synced:
+		return s.syncErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *src[T, PT, K]) add(ctx context.Context, w workqueue.TypedRateLimitingInterface[K], e Event[T, PT]) {
+	key := s.key(e.Object())
+	if s.recorder != nil {
+		s.recorder.RecordEvent(e.Type.String(), key, e.Old, e.New)
+	}
+	if ca, ok := w.(priorityqueue.ContextAdder[K]); ok {
+		ca.AddWithContext(s.context(ctx, e), priorityqueue.AddOpts{Priority: s.priority(e), EventTime: e.Time}, key)
+		return
+	}
+	pq, ok := w.(priorityqueue.PriorityQueue[K])
+	if !ok {
+		w.Add(key)
+		return
+	}
+	pq.AddWithOpts(priorityqueue.AddOpts{Priority: s.priority(e)}, key)
+}